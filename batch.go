@@ -0,0 +1,30 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BatchSpec describes a single cake to be built by LayeredBatch.
+type BatchSpec[T interface{}] struct {
+	Base   T
+	Layers []T
+}
+
+// LayeredBatch builds a cake for each of the specs. It always builds every spec and returns the
+// results in the same order, with a zero value for each spec that failed, along with an error
+// joining the errors of all failed specs and their indices.
+func LayeredBatch[T interface{}](specs []BatchSpec[T]) ([]T, error) {
+	var results = make([]T, len(specs))
+	var errs []error
+	for i, spec := range specs {
+		res, err := Layered[T](spec.Base, spec.Layers...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("spec %d: %w", i, err))
+			continue
+		}
+		results[i] = res
+	}
+
+	return results, errors.Join(errs...)
+}
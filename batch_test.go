@@ -0,0 +1,36 @@
+package cake
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_LayeredBatch(t *testing.T) {
+	results, err := LayeredBatch([]BatchSpec[Service]{
+		{Base: &LayerA{}, Layers: []Service{&brokenLayer{}}},
+		{Base: &LayerA{}, Layers: []Service{&LayerB{}}},
+		{Base: &LayerA{}, Layers: []Service{&LayerC{}, &brokenLayer{}}},
+	})
+
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	for _, expected := range []string{"spec 0: layer '*cake.brokenLayer'", "spec 2: layer '*cake.brokenLayer'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+	}
+
+	if strings.Contains(err.Error(), "spec 1") {
+		t.Fatalf("expected spec 1 to succeed, got %v", err)
+	}
+
+	if len(results) != 3 || results[0] != nil || results[2] != nil {
+		t.Fatalf("expected nil results for the failed specs, got %v", results)
+	}
+
+	if desc := Describe(results[1]); desc != "LayerB -> LayerA" {
+		t.Fatalf("expected LayerB -> LayerA, got %q", desc)
+	}
+}
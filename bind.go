@@ -0,0 +1,58 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// BindMethod looks up the method of chain with the given name once, and returns a func that calls
+// it with args and returns its results. This is useful for calling a method chosen at runtime in a
+// loop, without looking it up on every call. Arguments are passed to a variadic method the same way
+// as to a plain call, and a nil argument stands in for a zero value. The func returns an error,
+// without calling the method, if the arguments don't match its parameters.
+func BindMethod[T interface{}](chain T, method string) (func(args ...any) ([]any, error), error) {
+	var interfaceType = TypeOf[T]()
+
+	target, err := chainValue(chain)
+	if err != nil {
+		return nil, err
+	} else if _, ok := interfaceType.MethodByName(method); !ok {
+		return nil, fmt.Errorf("%s has no method %s", interfaceType, method)
+	}
+
+	fn := target.MethodByName(method)
+	fnType := fn.Type()
+
+	return func(args ...any) ([]any, error) {
+		if n := fnType.NumIn(); len(args) != n && !(fnType.IsVariadic() && len(args) >= n-1) {
+			return nil, fmt.Errorf("method %s takes %d arguments, got %d", method, n, len(args))
+		}
+
+		var in = make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var param reflect.Type
+			if fnType.IsVariadic() && i >= fnType.NumIn()-1 {
+				param = fnType.In(fnType.NumIn() - 1).Elem()
+			} else {
+				param = fnType.In(i)
+			}
+
+			if arg == nil {
+				in[i] = reflect.Zero(param)
+				continue
+			}
+
+			in[i] = reflect.ValueOf(arg)
+			if !in[i].Type().AssignableTo(param) {
+				return nil, fmt.Errorf("argument %d of method %s must be %s, got %T", i, method, param, arg)
+			}
+		}
+
+		var out = fn.Call(in)
+		var results = make([]any, len(out))
+		for i, result := range out {
+			results[i] = result.Interface()
+		}
+		return results, nil
+	}, nil
+}
@@ -0,0 +1,51 @@
+package cake
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_BindMethod(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	fruits, err := BindMethod(chain, "Fruits")
+	if err != nil {
+		t.Fatalf("failed to bind method: %+v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		results, err := fruits()
+		if err != nil {
+			t.Fatalf("failed to call method: %+v", err)
+		}
+
+		if len(results) != 1 || fmt.Sprint(results[0]) != "[Apple Durian Banana]" {
+			t.Fatalf("expected [[Apple Durian Banana]], got %v", results)
+		}
+	}
+
+	if _, err := fruits("extra"); err == nil || !strings.Contains(err.Error(), "takes 0 arguments, got 1") {
+		t.Fatalf("expected an argument count error, got %v", err)
+	}
+
+	store, err := BindMethod[Store](&mapStore{values: map[string]string{"a": "apple"}}, "Get")
+	if err != nil {
+		t.Fatalf("failed to bind method: %+v", err)
+	}
+
+	if results, err := store("a"); err != nil || results[0] != "apple" || results[1] != nil {
+		t.Fatalf("expected [apple <nil>], got %v (%v)", results, err)
+	}
+
+	if _, err := store(42); err == nil || !strings.Contains(err.Error(), "argument 0 of method Get must be string, got int") {
+		t.Fatalf("expected an argument type error, got %v", err)
+	}
+
+	if _, err := BindMethod(chain, "Herbs"); err == nil {
+		t.Fatalf("expected an error for an unknown method")
+	}
+}
@@ -0,0 +1,76 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by the proxy of WithCircuitBreaker while its circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// WithCircuitBreaker returns a proxy around chain that opens its circuit after threshold consecutive
+// calls have returned an error. While the circuit is open, calls return ErrCircuitOpen without
+// calling chain. Once reset has passed since the last error, the next call is let through: if it
+// succeeds the circuit closes again, if it fails the circuit stays open for another reset. Only
+// methods that return an error as their last value take part, and they all share one circuit.
+// Other methods are passed through.
+func WithCircuitBreaker[T interface{}](chain T, threshold int, reset time.Duration) (T, error) {
+	return withCircuitBreaker(chain, threshold, reset, time.Now)
+}
+
+// withCircuitBreaker is WithCircuitBreaker with the time told by now.
+func withCircuitBreaker[T interface{}](chain T, threshold int, reset time.Duration, now func() time.Time) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	} else if threshold < 1 {
+		return *new(T), fmt.Errorf("threshold must be at least 1, got %d", threshold)
+	}
+
+	var b = &breaker{threshold: threshold, reset: reset, now: now}
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if !returnsError(method.Type) {
+			return call(target, method, args)
+		} else if b.open() {
+			return errorResults(method, fmt.Errorf("%w: %s", ErrCircuitOpen, method.Name))
+		}
+
+		results := call(target, method, args)
+		b.record(!results[len(results)-1].IsNil())
+		return results
+	})
+}
+
+// breaker is the state of the circuit of WithCircuitBreaker.
+type breaker struct {
+	threshold int
+	reset     time.Duration
+	now       func() time.Time
+
+	mu          sync.Mutex
+	failures    int
+	lastFailure time.Time
+}
+
+// open reports whether calls should be rejected.
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold && b.now().Sub(b.lastFailure) < b.reset
+}
+
+// record records the outcome of a call that was let through.
+func (b *breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	b.lastFailure = b.now()
+}
@@ -0,0 +1,64 @@
+package cake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_WithCircuitBreaker(t *testing.T) {
+	flaky := &flakyLayer{n: 3}
+	chain, err := Layered[Store](&mapStore{values: map[string]string{"a": "apple"}}, flaky)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	svc, err := withCircuitBreaker(chain, 2, time.Minute, clock.Now)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Get("a"); !errors.Is(err, errStore) {
+			t.Fatalf("expected %v, got %v", errStore, err)
+		}
+	}
+
+	if _, err := svc.Get("a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected %v, got %v", ErrCircuitOpen, err)
+	}
+
+	if flaky.calls != 2 {
+		t.Fatalf("expected the open circuit not to call the chain, got %d calls", flaky.calls)
+	}
+
+	if n := svc.Len(); n != 1 {
+		t.Fatalf("expected Len to pass through, got %d", n)
+	}
+
+	// the circuit stays open until the reset has passed
+	clock.Advance(time.Minute - time.Second)
+	if _, err := svc.Get("a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected %v, got %v", ErrCircuitOpen, err)
+	}
+
+	// the call let through after the reset fails, which keeps the circuit open
+	clock.Advance(time.Second)
+	if _, err := svc.Get("a"); !errors.Is(err, errStore) {
+		t.Fatalf("expected %v, got %v", errStore, err)
+	}
+
+	if _, err := svc.Get("a"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected %v, got %v", ErrCircuitOpen, err)
+	}
+
+	clock.Advance(time.Minute)
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected apple, got %q (%v)", v, err)
+	}
+
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected the circuit to be closed, got %q (%v)", v, err)
+	}
+}
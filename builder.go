@@ -0,0 +1,30 @@
+package cake
+
+import (
+	"sync"
+)
+
+// Builder builds cakes of T with a fixed set of options, like Config, and caches the delegate field
+// it resolves for each type of layer. Building many cakes from the same types of layers through one
+// Builder only resolves each field once. A Builder is safe for use by multiple goroutines, as long
+// as each build is given its own layers. The cache is read lock-free once a type of layer has been
+// seen, so concurrent builds don't contend on it.
+type Builder[T interface{}] struct {
+	opts  []Option
+	cache sync.Map
+}
+
+// NewBuilder returns a Builder with the given options. A FieldResolver given with WithFieldResolver
+// must return the same result for the same types every time, since its results are cached.
+func NewBuilder[T interface{}](opts ...Option) *Builder[T] {
+	return &Builder[T]{opts: append([]Option(nil), opts...)}
+}
+
+// Build calls LayeredWith with the options of the builder, resolving delegate fields through its
+// cache.
+func (b *Builder[T]) Build(base T, layers ...T) (T, error) {
+	var opts = make([]Option, 0, len(b.opts)+1)
+	opts = append(opts, b.opts...)
+	opts = append(opts, func(o *options) { o.cache = &b.cache })
+	return LayeredWith[T](base, opts, layers...)
+}
@@ -0,0 +1,64 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// fruitLayer adds its own fruit.
+type fruitLayer struct {
+	Service
+	fruit string
+}
+
+func (l *fruitLayer) Fruits() []string {
+	return append(l.Service.Fruits(), l.fruit)
+}
+
+func Test_Builder(t *testing.T) {
+	var resolves int
+	var mu sync.Mutex
+	builder := NewBuilder[Service](WithFieldResolver(func(layerType, interfaceType reflect.Type) ([]int, error) {
+		mu.Lock()
+		resolves++
+		mu.Unlock()
+		return defaultFieldResolver(layerType, interfaceType)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			fruit := fmt.Sprintf("Fruit%d", i)
+			svc, err := builder.Build(&LayerA{}, &LayerB{}, &fruitLayer{fruit: fruit}, &LayerD{})
+			if err != nil {
+				t.Errorf("failed to layer cake: %+v", err)
+				return
+			}
+
+			expectedFruits := []string{"Apple", "Durian", fruit, "Banana"}
+			if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+				t.Errorf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// concurrent builds may each resolve a type of layer until it has been cached once
+	if resolves < 3 || resolves > 3*2000 {
+		t.Fatalf("expected the resolver to be called for each type of layer, got %d calls", resolves)
+	}
+
+	before := resolves
+	if _, err := builder.Build(&LayerA{}, &LayerB{}, &fruitLayer{}, &LayerD{}); err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if resolves != before {
+		t.Fatalf("expected the field of each type of layer to be cached, got %d more calls", resolves-before)
+	}
+}
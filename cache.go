@@ -0,0 +1,112 @@
+package cake
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithCache returns a proxy around chain that caches the results of calls for ttl. Each call is
+// cached under the key keyFn returns for its method name and arguments, and a call whose key is
+// found and has not expired returns the cached results without calling chain. Returning "" from
+// keyFn disables caching for that call. Only methods that return a value other than an error take
+// part, and results with a non-nil error are not cached. Other methods are passed through.
+//
+// Expired results are dropped when their key is called again, and every expired result is dropped
+// whenever the number of cached results has doubled, so the cache only grows with the number of
+// keys called within ttl. Cached results are returned as-is, so a method returning a slice or a map
+// shares it across calls.
+func WithCache[T interface{}](chain T, keyFn func(method string, args []reflect.Value) string, ttl time.Duration) (T, error) {
+	return withCache(chain, keyFn, newResultCache(ttl, time.Now))
+}
+
+// withCache is WithCache with the results cached in c.
+func withCache[T interface{}](chain T, keyFn func(method string, args []reflect.Value) string, c *resultCache) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if n := method.Type.NumOut(); n == 0 || (n == 1 && returnsError(method.Type)) {
+			return call(target, method, args)
+		}
+
+		key := keyFn(method.Name, args)
+		if key == "" {
+			return call(target, method, args)
+		}
+
+		if results, ok := c.get(method.Name, key); ok {
+			return results
+		}
+
+		results := call(target, method, args)
+		if returnsError(method.Type) && !results[len(results)-1].IsNil() {
+			return results
+		}
+
+		c.put(method.Name, key, results)
+		return results
+	})
+}
+
+// minSweep is the number of cached results at which a resultCache first drops expired results.
+const minSweep = 64
+
+// resultCache holds the results cached by WithCache, keyed by method name and key.
+type resultCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[[2]string]cacheEntry
+	// sweepAt is the number of entries at which the expired entries are dropped next.
+	sweepAt int
+}
+
+type cacheEntry struct {
+	results []reflect.Value
+	expires time.Time
+}
+
+func newResultCache(ttl time.Duration, now func() time.Time) *resultCache {
+	return &resultCache{ttl: ttl, now: now, entries: make(map[[2]string]cacheEntry), sweepAt: minSweep}
+}
+
+// get returns the results cached for key, dropping them if they have expired.
+func (c *resultCache) get(method, key string) ([]reflect.Value, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[[2]string{method, key}]
+	if !ok {
+		return nil, false
+	} else if !c.now().Before(entry.expires) {
+		delete(c.entries, [2]string{method, key})
+		return nil, false
+	}
+	return entry.results, true
+}
+
+// put caches results for key until ttl has passed.
+func (c *resultCache) put(method, key string, results []reflect.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.entries[[2]string{method, key}] = cacheEntry{results: results, expires: now.Add(c.ttl)}
+	if len(c.entries) < c.sweepAt {
+		return
+	}
+
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.sweepAt = 2 * len(c.entries)
+	if c.sweepAt < minSweep {
+		c.sweepAt = minSweep
+	}
+}
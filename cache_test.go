@@ -0,0 +1,104 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when it is advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func Test_WithCache(t *testing.T) {
+	counter := &flakyLayer{}
+	chain, err := Layered[Store](&mapStore{values: map[string]string{"a": "apple"}}, counter)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := newResultCache(time.Minute, clock.Now)
+	svc, err := withCache(chain, func(method string, args []reflect.Value) string {
+		if args[0].String() == "uncached" {
+			return ""
+		}
+		return args[0].String()
+	}, cache)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if v, err := svc.Get("a"); err != nil || v != "apple" {
+			t.Fatalf("expected apple, got %q (%v)", v, err)
+		}
+	}
+
+	if counter.calls != 1 {
+		t.Fatalf("expected the cached method to be called once, got %d calls", counter.calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Get("missing"); !errors.Is(err, errStore) {
+			t.Fatalf("expected %v, got %v", errStore, err)
+		}
+		_, _ = svc.Get("uncached")
+	}
+
+	if counter.calls != 5 {
+		t.Fatalf("expected errors and disabled keys not to be cached, got %d calls", counter.calls)
+	}
+
+	clock.Advance(time.Minute)
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected apple, got %q (%v)", v, err)
+	}
+
+	if counter.calls != 6 {
+		t.Fatalf("expected the method to be called again after the ttl, got %d calls", counter.calls)
+	}
+}
+
+func Test_WithCache_DropsExpired(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cache := newResultCache(time.Minute, clock.Now)
+	svc, err := withCache[Store](&mapStore{values: make(map[string]string)}, func(method string, args []reflect.Value) string {
+		return args[0].String()
+	}, cache)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	_ = svc.Put("a", "apple")
+	if _, err := svc.Get("a"); err != nil {
+		t.Fatalf("expected apple, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := svc.Get("a"); err != nil || len(cache.entries) != 1 {
+		t.Fatalf("expected the expired result to be replaced, got %d entries (%v)", len(cache.entries), err)
+	}
+
+	// expired keys that are never called again are dropped once the cache has doubled
+	for i := 0; i < minSweep; i++ {
+		_ = svc.Put(fmt.Sprint(i), "value")
+		_, _ = svc.Get(fmt.Sprint(i))
+	}
+	clock.Advance(time.Minute)
+	for i := 0; i < minSweep; i++ {
+		_ = svc.Put(fmt.Sprint("new", i), "value")
+		_, _ = svc.Get(fmt.Sprint("new", i))
+	}
+
+	if len(cache.entries) > minSweep+1 {
+		t.Fatalf("expected the expired results to be dropped, got %d entries", len(cache.entries))
+	}
+}
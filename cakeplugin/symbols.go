@@ -0,0 +1,35 @@
+// Package cakeplugin assembles layered cakes from layers loaded with the plugin package. It is kept
+// separate from package cake so that programs that don't use plugins don't link in package plugin.
+package cakeplugin
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/tylermmorton/cake"
+)
+
+// LayeredFromSymbols is like cake.Layered, but takes the layers as symbols looked up in plugins,
+// ordered from the outermost layer inward. Each symbol must either implement T, or be a pointer to a
+// variable of type T, which is what plugin.Plugin.Lookup returns for an exported variable. A nil
+// symbol is skipped. It returns an error with the index of the first symbol that is neither.
+func LayeredFromSymbols[T interface{}](base T, symbols []plugin.Symbol) (T, error) {
+	var layers = make([]T, 0, len(symbols))
+	for i, symbol := range symbols {
+		switch layer := symbol.(type) {
+		case nil:
+			continue
+		case T:
+			layers = append(layers, layer)
+		case *T:
+			if layer == nil {
+				continue
+			}
+			layers = append(layers, *layer)
+		default:
+			return *new(T), fmt.Errorf("symbol %d: %s", i, cake.WhyNotAssignable[T](symbol))
+		}
+	}
+
+	return cake.Layered[T](base, layers...)
+}
@@ -0,0 +1,46 @@
+package cakeplugin
+
+import (
+	"fmt"
+	"plugin"
+	"strings"
+	"testing"
+)
+
+type Service interface {
+	Fruits() []string
+}
+
+type baseLayer struct{}
+
+func (baseLayer) Fruits() []string { return []string{"Apple"} }
+
+type fruitLayer struct {
+	Service
+	fruit string
+}
+
+func (l *fruitLayer) Fruits() []string {
+	return append(l.Service.Fruits(), l.fruit)
+}
+
+func Test_LayeredFromSymbols(t *testing.T) {
+	// an exported variable of an interface type is looked up as a pointer to the variable
+	var exported Service = &fruitLayer{fruit: "Cherry"}
+
+	symbols := []plugin.Symbol{&fruitLayer{fruit: "Banana"}, nil, &exported}
+	svc, err := LayeredFromSymbols[Service](baseLayer{}, symbols)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Cherry", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	_, err = LayeredFromSymbols[Service](baseLayer{}, []plugin.Symbol{&fruitLayer{}, "not a layer"})
+	if err == nil || !strings.HasPrefix(err.Error(), "symbol 1: string does not implement cakeplugin.Service") {
+		t.Fatalf("expected an error naming symbol 1, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package caketest
+
+import (
+	"testing"
+
+	"github.com/tylermmorton/cake"
+)
+
+// Must calls cake.Layered and fails the test immediately if the cake cannot be wired. In test setup
+// a wiring error is a programming mistake, so this saves checking the error of every cake.
+func Must[T interface{}](t testing.TB, base T, layers ...T) T {
+	t.Helper()
+
+	chain, err := cake.Layered[T](base, layers...)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+	return chain
+}
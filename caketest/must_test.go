@@ -0,0 +1,60 @@
+package caketest
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeTB records calls to Fatalf, Errorf and Logf instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	fatals []string
+	errors []string
+	logs   []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Failed() bool {
+	return len(tb.fatals) != 0 || len(tb.errors) != 0
+}
+
+func (tb *fakeTB) Logf(format string, args ...any) {
+	tb.logs = append(tb.logs, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Fatalf(format string, args ...any) {
+	tb.fatals = append(tb.fatals, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+type brokenLayer struct {
+	service Service
+}
+
+func (l *brokenLayer) Fruits() []string {
+	return l.service.Fruits()
+}
+
+func Test_Must(t *testing.T) {
+	tb := &fakeTB{}
+	svc := Must[Service](tb, &LayerA{}, &LayerB{})
+	if len(tb.fatals) != 0 {
+		t.Fatalf("expected no failures, got %v", tb.fatals)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+}
+
+func Test_Must_Fails(t *testing.T) {
+	tb := &fakeTB{}
+	Must[Service](tb, &LayerA{}, &brokenLayer{})
+	if len(tb.fatals) != 1 {
+		t.Fatalf("expected one failure, got %v", tb.fatals)
+	}
+}
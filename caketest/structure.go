@@ -0,0 +1,41 @@
+package caketest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tylermmorton/cake"
+)
+
+// AssertStructure fails the test if the names of the concrete types in chain, from the outermost
+// layer inward and ending with the base, are not want. Names are given without their package or
+// pointer indirection, as in cake.Describe. The failure lists each position of the chain where the
+// two differ.
+func AssertStructure[T interface{}](t testing.TB, chain T, want []string) {
+	t.Helper()
+
+	var got []string
+	if described := cake.Describe(chain); described != "" {
+		got = strings.Split(described, " -> ")
+	}
+
+	var diff []string
+	for i := 0; i < len(got) || i < len(want); i++ {
+		var g, w = "<none>", "<none>"
+		if i < len(got) {
+			g = got[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if g != w {
+			diff = append(diff, fmt.Sprintf("  %d: want %s, got %s", i, w, g))
+		}
+	}
+
+	if len(diff) != 0 {
+		t.Errorf("unexpected cake structure\nwant: %s\ngot:  %s\n%s",
+			strings.Join(want, " -> "), strings.Join(got, " -> "), strings.Join(diff, "\n"))
+	}
+}
@@ -0,0 +1,31 @@
+package caketest
+
+import (
+	"testing"
+)
+
+func Test_AssertStructure(t *testing.T) {
+	chain := Must[Service](t, &LayerA{}, &LayerB{})
+
+	tb := &fakeTB{}
+	AssertStructure[Service](tb, chain, []string{"LayerB", "LayerA"})
+	if len(tb.errors) != 0 {
+		t.Fatalf("expected no failures, got %v", tb.errors)
+	}
+
+	tb = &fakeTB{}
+	AssertStructure[Service](tb, chain, []string{"LayerA", "LayerB", "LayerC"})
+	if len(tb.errors) != 1 {
+		t.Fatalf("expected one failure, got %v", tb.errors)
+	}
+
+	expected := "unexpected cake structure\n" +
+		"want: LayerA -> LayerB -> LayerC\n" +
+		"got:  LayerB -> LayerA\n" +
+		"  0: want LayerA, got LayerB\n" +
+		"  1: want LayerB, got LayerA\n" +
+		"  2: want LayerC, got <none>"
+	if tb.errors[0] != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, tb.errors[0])
+	}
+}
@@ -0,0 +1,52 @@
+// Package caketest provides utilities for testing layered cakes.
+package caketest
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/tylermmorton/cake"
+)
+
+// Case is a single named test case in a Table.
+type Case[T interface{}] struct {
+	// Build constructs the cake under test.
+	Build func() (T, error)
+	// Test makes assertions against the cake returned by Build.
+	Test func(t *testing.T, chain T)
+}
+
+// Table is a set of named test cases for a layered cake. Unlike ranging over a map of test cases,
+// Run runs the cases in sorted order, which makes failures easier to reproduce and compare.
+type Table[T interface{}] map[string]Case[T]
+
+// Run runs each case of the table as a subtest of t, in order of name. When a case fails, the
+// layers of its cake are logged using cake.Describe.
+func (tbl Table[T]) Run(t *testing.T) {
+	var names = make([]string, 0, len(tbl))
+	for name := range tbl {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		testCase := tbl[name]
+		t.Run(name, func(t *testing.T) {
+			chain, err := testCase.Build()
+			if err != nil {
+				t.Fatalf("failed to layer cake: %+v", err)
+			}
+
+			defer describeOnFailure[T](t, chain)
+
+			testCase.Test(t, chain)
+		})
+	}
+}
+
+// describeOnFailure logs the layers of chain using cake.Describe if t has failed.
+func describeOnFailure[T interface{}](t testing.TB, chain T) {
+	if t.Failed() {
+		t.Logf("cake: %s", cake.Describe(chain))
+	}
+}
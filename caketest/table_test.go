@@ -0,0 +1,86 @@
+package caketest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tylermmorton/cake"
+)
+
+type Service interface {
+	Fruits() []string
+}
+
+type LayerA struct{ Service }
+
+func (l *LayerA) Fruits() []string {
+	return []string{"Apple"}
+}
+
+type LayerB struct{ Service }
+
+func (l *LayerB) Fruits() []string {
+	return append(l.Service.Fruits(), "Banana")
+}
+
+func Test_Table(t *testing.T) {
+	var order []string
+	var record = func(t *testing.T, chain Service) {
+		order = append(order, t.Name())
+	}
+
+	Table[Service]{
+		"c": {Build: func() (Service, error) { return cake.Layered[Service](&LayerA{}) }, Test: record},
+		"a": {Build: func() (Service, error) { return cake.Layered[Service](&LayerA{}, &LayerB{}) }, Test: record},
+		"b": {Build: func() (Service, error) { return cake.Layered[Service](&LayerA{}, &LayerB{}) }, Test: record},
+	}.Run(t)
+
+	expected := []string{"Test_Table/a", "Test_Table/b", "Test_Table/c"}
+	if fmt.Sprint(order) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+}
+
+func Test_Table_Chain(t *testing.T) {
+	Table[Service]{
+		"Passes the built chain to the test": {
+			Build: func() (Service, error) { return cake.Layered[Service](&LayerA{}, &LayerB{}) },
+			Test: func(t *testing.T, chain Service) {
+				if fmt.Sprint(chain.Fruits()) != "[Apple Banana]" {
+					t.Fatalf("expected [Apple Banana], got %v", chain.Fruits())
+				}
+			},
+		},
+	}.Run(t)
+}
+
+func Test_Table_DescribeOnFailure(t *testing.T) {
+	chain := Must[Service](t, &LayerA{}, &LayerB{})
+
+	testTable := map[string]struct {
+		fail     bool
+		expected []string
+	}{
+		"Logs the layers when the test fails": {
+			fail:     true,
+			expected: []string{"cake: LayerB -> LayerA"},
+		},
+		"Logs nothing when the test passes": {
+			fail:     false,
+			expected: nil,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			tb := &fakeTB{}
+			if testCase.fail {
+				tb.Errorf("expected [Apple], got %v", chain.Fruits())
+			}
+
+			describeOnFailure[Service](tb, chain)
+			if fmt.Sprint(tb.logs) != fmt.Sprint(testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, tb.logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package cake
+
+// Chain holds a layered cake along with metadata about how it was built, such as a config hash or
+// the feature flags that were enabled. This keeps such data next to the cake without adding it to
+// the interface. A Chain is created with LayeredMeta. It is not safe to call WithMeta concurrently
+// with other methods.
+type Chain[T interface{}] struct {
+	value T
+	meta  map[string]any
+	// names holds the names of the layers of a chain built with LayeredNamed.
+	names map[any]string
+}
+
+// LayeredMeta is like Layered, but returns the cake as a Chain without any metadata.
+func LayeredMeta[T interface{}](base T, layers ...T) (*Chain[T], error) {
+	res, err := Layered[T](base, layers...)
+	if err != nil {
+		return nil, err
+	}
+	return &Chain[T]{value: res, meta: make(map[string]any)}, nil
+}
+
+// Value returns the outermost layer of the cake.
+func (c *Chain[T]) Value() T {
+	return c.value
+}
+
+// Meta returns a copy of the metadata of the chain.
+func (c *Chain[T]) Meta() map[string]any {
+	var meta = make(map[string]any, len(c.meta))
+	for k, v := range c.meta {
+		meta[k] = v
+	}
+	return meta
+}
+
+// WithMeta sets the metadata key k to v and returns the chain.
+func (c *Chain[T]) WithMeta(k string, v any) *Chain[T] {
+	c.meta[k] = v
+	return c
+}
+
+// Describe is like the Describe func, but names the layers that were built with LayeredNamed by
+// their names.
+func (c *Chain[T]) Describe() string {
+	return describe(c.value, func(layer any) string { return lookupName(c.names, layer) })
+}
@@ -0,0 +1,34 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_LayeredMeta(t *testing.T) {
+	chain, err := LayeredMeta[Service](&LayerA{}, &LayerB{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	chain.WithMeta("config", "abc123").WithMeta("flags", []string{"durian"})
+
+	meta := chain.Meta()
+	if meta["config"] != "abc123" || fmt.Sprint(meta["flags"]) != "[durian]" {
+		t.Fatalf("expected the metadata to be set, got %v", meta)
+	}
+
+	meta["config"] = "changed"
+	if chain.Meta()["config"] != "abc123" {
+		t.Fatalf("expected Meta to return a copy")
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(chain.Value().Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, chain.Value().Fruits())
+	}
+
+	if _, err := LayeredMeta[Service](&LayerA{}, &brokenLayer{}); err == nil {
+		t.Fatalf("expected an error for a layer that cannot be wired")
+	}
+}
@@ -0,0 +1,56 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// stopper is implemented by values that are shut down with Stop rather than Close.
+type stopper interface {
+	Stop() error
+}
+
+// LayeredWithCleanup is like Layered, but also returns a func that tears the cake down. The func
+// calls Close() error or Stop() error on every layer that declares one of them itself, from the
+// outermost inward, and on the base last, so that each value is stopped before the values it
+// delegates to. Values that don't declare either method are skipped. The errors of all calls are
+// joined with errors.Join. The func should be called once, e.g. with defer.
+func LayeredWithCleanup[T interface{}](base T, layers ...T) (T, func() error, error) {
+	res, err := Layered[T](base, layers...)
+	if err != nil {
+		return *new(T), nil, err
+	}
+
+	var values []any
+	for _, layer := range layers {
+		if _, ok := getLayerValue(layer); ok {
+			values = append(values, layer)
+		}
+	}
+	values = append(values, base)
+
+	var cleanup = func() error {
+		var errs []error
+		for _, value := range values {
+			if err := closeValue(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", layerName(value), err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	return res, cleanup, nil
+}
+
+// closeValue calls Close or Stop on value if its concrete type declares the method itself. Methods
+// promoted from the embedded next layer are not called, since the next layer is closed on its own.
+func closeValue(value any) error {
+	value = unwrapStandIn(value)
+	t := reflect.TypeOf(value)
+	if closer, ok := value.(interface{ Close() error }); ok && definesMethod(t, "Close") {
+		return closer.Close()
+	} else if stopper, ok := value.(stopper); ok && definesMethod(t, "Stop") {
+		return stopper.Stop()
+	}
+	return nil
+}
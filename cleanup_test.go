@@ -0,0 +1,60 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// closingLayer records when it is closed into a shared log.
+type closingLayer struct {
+	Service
+	name string
+	log  *[]string
+	err  error
+}
+
+func (l *closingLayer) Close() error {
+	*l.log = append(*l.log, "close "+l.name)
+	return l.err
+}
+
+// stoppingBase records when it is stopped into a shared log.
+type stoppingBase struct {
+	LayerA
+	log *[]string
+}
+
+func (b *stoppingBase) Stop() error {
+	*b.log = append(*b.log, "stop base")
+	return nil
+}
+
+func Test_LayeredWithCleanup(t *testing.T) {
+	var log []string
+	errOuter, errInner := errors.New("outer failed"), errors.New("inner failed")
+
+	svc, cleanup, err := LayeredWithCleanup[Service](&stoppingBase{log: &log},
+		&closingLayer{name: "outer", log: &log, err: errOuter},
+		&LayerB{},
+		nil,
+		&closingLayer{name: "inner", log: &log, err: errInner},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	err = cleanup()
+	if !errors.Is(err, errOuter) || !errors.Is(err, errInner) {
+		t.Fatalf("expected both errors to be joined, got %v", err)
+	}
+
+	expectedLog := []string{"close outer", "close inner", "stop base"}
+	if fmt.Sprint(log) != fmt.Sprint(expectedLog) {
+		t.Fatalf("expected %v, got %v", expectedLog, log)
+	}
+}
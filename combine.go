@@ -0,0 +1,187 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConflictPolicy decides what CombineWith does when more than one implementation declares the
+// same method.
+type ConflictPolicy int
+
+const (
+	// ConflictError makes CombineWith return an error. This is the default.
+	ConflictError ConflictPolicy = iota
+	// FirstWins dispatches the method to the first implementation that declares it.
+	FirstWins
+	// LastWins dispatches the method to the last implementation that declares it.
+	LastWins
+)
+
+// Combine synthesizes a single T out of several partial implementations. Each method of T is
+// dispatched to the one implementation whose concrete type has a method with the same name and
+// signature, declared itself or promoted from an embedded struct. Methods promoted from an
+// embedded interface, such as T itself, are not counted, since such a field is typically left
+// unset in a partial implementation. It returns an error if a method of T is not declared by any implementation, or
+// if it is declared by more than one. This is useful for splitting a large interface into smaller
+// implementations, each with its own natural base. Combine requires a proxy registered for T with
+// RegisterProxy.
+func Combine[T interface{}](impls ...any) (T, error) {
+	return CombineWith[T](ConflictError, impls...)
+}
+
+// CombineWith is like Combine, but policy decides which implementation a method is dispatched to
+// when more than one implementation declares it.
+func CombineWith[T interface{}](policy ConflictPolicy, impls ...any) (T, error) {
+	var interfaceType = TypeOf[T]()
+	var dispatch = make(map[string]reflect.Value, interfaceType.NumMethod())
+	var missing []string
+
+	for i := 0; i < interfaceType.NumMethod(); i++ {
+		method := interfaceType.Method(i)
+
+		var owner any
+		for _, impl := range impls {
+			fn, ok := declaredMethod(impl, method)
+			if !ok {
+				continue
+			} else if owner != nil {
+				if policy == FirstWins {
+					continue
+				} else if policy != LastWins {
+					return *new(T), fmt.Errorf("method %s is defined by both '%T' and '%T'", method.Name, owner, impl)
+				}
+			}
+			owner = impl
+			dispatch[method.Name] = fn
+		}
+
+		if owner == nil {
+			missing = append(missing, method.Name)
+		}
+	}
+
+	if len(missing) != 0 {
+		return *new(T), fmt.Errorf("no implementation of %s defines %s", interfaceType, strings.Join(missing, ", "))
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		return callFunc(dispatch[method.Name], args)
+	})
+}
+
+// declaredMethod returns the bound method of impl matching method, unless impl only has it through
+// an embedded field of an interface type.
+func declaredMethod(impl any, method reflect.Method) (reflect.Value, bool) {
+	val := reflect.ValueOf(impl)
+	if !val.IsValid() {
+		return reflect.Value{}, false
+	} else if from := promotedFrom(val.Type(), method.Name); from != nil && from.Kind() == reflect.Interface && !definesMethod(val.Type(), method.Name) {
+		return reflect.Value{}, false
+	}
+
+	fn := val.MethodByName(method.Name)
+	if !fn.IsValid() || fn.Type() != method.Type {
+		return reflect.Value{}, false
+	}
+	return fn, true
+}
+
+// promotedFrom returns the type of the embedded field the method name of t is promoted from, going
+// through embedded structs the same way Go does: the shallowest field that provides the method wins.
+// It returns nil if the method is not promoted from an embedded field.
+func promotedFrom(t reflect.Type, name string) reflect.Type {
+	var level = []reflect.Type{t}
+	for len(level) != 0 {
+		var next []reflect.Type
+		for _, t := range level {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Struct {
+				continue
+			}
+
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if !field.Anonymous {
+					continue
+				} else if field.Type.Kind() == reflect.Interface {
+					if _, ok := field.Type.MethodByName(name); ok {
+						return field.Type
+					}
+					continue
+				}
+
+				// methods with a value receiver are in the method set of the pointer too
+				if definesMethod(reflect.PointerTo(field.Type), name) {
+					return field.Type
+				}
+				next = append(next, field.Type)
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// Adapt returns a proxy for To that calls the methods To shares with From on from, and every
+// other method on defaults. Methods are shared when they have the same name and signature. This is
+// useful for reusing layers written against an older version of an interface in a cake of a newer
+// version that adds methods. Adapt requires a proxy registered for To with RegisterProxy.
+func Adapt[From interface{}, To interface{}](from From, defaults To) (To, error) {
+	var fromType = TypeOf[From]()
+
+	fromVal, err := chainValue(from)
+	if err != nil {
+		return *new(To), err
+	}
+
+	defaultsVal, err := chainValue(defaults)
+	if err != nil {
+		return *new(To), fmt.Errorf("defaults: %w", err)
+	}
+
+	return newProxy[To](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if shared, ok := fromType.MethodByName(method.Name); ok && shared.Type == method.Type {
+			return call(fromVal, method, args)
+		}
+		return call(defaultsVal, method, args)
+	})
+}
+
+// WithFallback returns a proxy for T that calls a method on primary if any value in its chain
+// declares the method itself, and on fallback otherwise. This is meant for partial chains, whose
+// layers leave some methods to an unset embedded field that would panic when called. Like Combine,
+// this is decided for each method when the proxy is created. WithFallback requires a proxy
+// registered for T with RegisterProxy.
+func WithFallback[T interface{}](primary T, fallback T) (T, error) {
+	var interfaceType = TypeOf[T]()
+
+	primaryVal, err := chainValue(primary)
+	if err != nil {
+		return *new(T), fmt.Errorf("primary: %w", err)
+	}
+
+	fallbackVal, err := chainValue(fallback)
+	if err != nil {
+		return *new(T), fmt.Errorf("fallback: %w", err)
+	}
+
+	var handled = make(map[string]bool, interfaceType.NumMethod())
+	WalkLayers(primary, func(layer T, _ int) bool {
+		for i := 0; i < interfaceType.NumMethod(); i++ {
+			name := interfaceType.Method(i).Name
+			handled[name] = handled[name] || definesMethod(reflect.TypeOf(layer), name)
+		}
+		return true
+	})
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if handled[method.Name] {
+			return call(primaryVal, method, args)
+		}
+		return call(fallbackVal, method, args)
+	})
+}
@@ -0,0 +1,263 @@
+package cake
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fruitStand struct{}
+
+func (fruitStand) Fruits() []string { return []string{"Fig"} }
+
+type veggieStand struct{}
+
+func (*veggieStand) Veggies() []string { return []string{"Kale"} }
+
+func Test_Combine(t *testing.T) {
+	svc, err := Combine[Service](fruitStand{}, &veggieStand{})
+	if err != nil {
+		t.Fatalf("failed to combine: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Fig]" {
+		t.Fatalf("expected [Fig], got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Kale]" {
+		t.Fatalf("expected [Kale], got %v", svc.Veggies())
+	}
+
+	// the combined service can be used as the base of a cake
+	layered, err := Layered[Service](svc, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(layered.Fruits()) != "[Fig Durian]" {
+		t.Fatalf("expected [Fig Durian], got %v", layered.Fruits())
+	}
+}
+
+// commonFruits is embedded by partial implementations that share its Fruits.
+type commonFruits struct{}
+
+func (commonFruits) Fruits() []string { return []string{"Guava"} }
+
+// embeddedFruitStand gets Fruits from an embedded struct rather than declaring it.
+type embeddedFruitStand struct{ commonFruits }
+
+// shadowedFruitStand embeds Service next to embeddedFruitStand. The Fruits of Service is shallower
+// than the one of commonFruits, so it is the one that is promoted.
+type shadowedFruitStand struct {
+	*embeddedFruitStand
+	Service
+}
+
+// FruitsOnly is a narrower interface than Service.
+type FruitsOnly interface {
+	Fruits() []string
+}
+
+// narrowVeggieStand declares Veggies, and its Fruits is promoted from an unset FruitsOnly.
+type narrowVeggieStand struct{ FruitsOnly }
+
+func (*narrowVeggieStand) Veggies() []string { return []string{"Leek"} }
+
+func Test_Combine_EmbeddedInterface(t *testing.T) {
+	svc, err := Combine[Service](fruitStand{}, &narrowVeggieStand{})
+	if err != nil {
+		t.Fatalf("failed to combine: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Fig]" || fmt.Sprint(svc.Veggies()) != "[Leek]" {
+		t.Fatalf("expected [Fig] and [Leek], got %v and %v", svc.Fruits(), svc.Veggies())
+	}
+}
+
+func Test_Combine_EmbeddedStruct(t *testing.T) {
+	svc, err := Combine[Service](embeddedFruitStand{}, &veggieStand{})
+	if err != nil {
+		t.Fatalf("failed to combine: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Guava]" {
+		t.Fatalf("expected [Guava], got %v", svc.Fruits())
+	}
+
+	testTable := map[string]struct {
+		impl     any
+		expected string
+	}{
+		"Methods promoted from the embedded interface are not counted": {
+			impl:     &fruitsOnlyLayer{},
+			expected: "no implementation of cake.Service defines Veggies",
+		},
+		"The shallowest embedded field wins": {
+			impl:     &shadowedFruitStand{embeddedFruitStand: &embeddedFruitStand{}},
+			expected: "no implementation of cake.Service defines Fruits, Veggies",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := Combine[Service](testCase.impl)
+			if err == nil || err.Error() != testCase.expected {
+				t.Fatalf("expected %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func Test_Combine_Errors(t *testing.T) {
+	testTable := map[string]struct {
+		impls    []any
+		expected string
+	}{
+		"Errors when a method is not implemented": {
+			impls:    []any{fruitStand{}},
+			expected: "defines Veggies",
+		},
+		"Errors when a method is implemented more than once": {
+			impls:    []any{fruitStand{}, &veggieStand{}, &LayerA{}},
+			expected: "method Fruits is defined by both",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := Combine[Service](testCase.impls...)
+			if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+type ServiceV2 interface {
+	Service
+	Herbs() []string
+}
+
+type serviceV2Proxy struct {
+	FruitsFunc  func() []string
+	VeggiesFunc func() []string
+	HerbsFunc   func() []string
+}
+
+func (p *serviceV2Proxy) Fruits() []string  { return p.FruitsFunc() }
+func (p *serviceV2Proxy) Veggies() []string { return p.VeggiesFunc() }
+func (p *serviceV2Proxy) Herbs() []string   { return p.HerbsFunc() }
+
+type baseV2 struct{}
+
+func (baseV2) Fruits() []string  { return []string{"Apple"} }
+func (baseV2) Veggies() []string { return []string{"Artichoke"} }
+func (baseV2) Herbs() []string   { return []string{"Basil"} }
+
+type herbLayerV2 struct{ ServiceV2 }
+
+func (l *herbLayerV2) Herbs() []string { return append(l.ServiceV2.Herbs(), "Hyssop") }
+
+func Test_Adapt(t *testing.T) {
+	// a V1 layer is wired over the V2 chain it adapts, so its calls still end up in the V2 base
+	base := baseV2{}
+	v1, err := Layered[Service](base, &LayerB{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	adapted, err := Adapt[Service, ServiceV2](v1, base)
+	if err != nil {
+		t.Fatalf("failed to adapt: %+v", err)
+	}
+
+	svc, err := Layered[ServiceV2](adapted, &herbLayerV2{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Herbs()) != "[Basil Hyssop]" {
+		t.Fatalf("expected [Basil Hyssop], got %v", svc.Herbs())
+	}
+}
+
+type otherFruitStand struct{}
+
+func (otherFruitStand) Fruits() []string { return []string{"Guava"} }
+
+func Test_CombineWith(t *testing.T) {
+	testTable := map[string]struct {
+		policy         ConflictPolicy
+		expectedFruits string
+		expectedErr    string
+	}{
+		"FirstWins": {
+			policy:         FirstWins,
+			expectedFruits: "[Fig]",
+		},
+		"LastWins": {
+			policy:         LastWins,
+			expectedFruits: "[Guava]",
+		},
+		"ConflictError": {
+			policy:      ConflictError,
+			expectedErr: "method Fruits is defined by both 'cake.fruitStand' and 'cake.otherFruitStand'",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			svc, err := CombineWith[Service](testCase.policy, fruitStand{}, &veggieStand{}, otherFruitStand{})
+			if testCase.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("failed to combine: %+v", err)
+			}
+
+			if fmt.Sprint(svc.Fruits()) != testCase.expectedFruits {
+				t.Fatalf("expected %s, got %v", testCase.expectedFruits, svc.Fruits())
+			}
+
+			if fmt.Sprint(svc.Veggies()) != "[Kale]" {
+				t.Fatalf("expected [Kale], got %v", svc.Veggies())
+			}
+		})
+	}
+}
+
+func Test_Combine_MissingMethod(t *testing.T) {
+	_, err := Combine[Service](fruitStand{})
+	if err == nil || err.Error() != "no implementation of cake.Service defines Veggies" {
+		t.Fatalf("expected an error listing the missing method, got %v", err)
+	}
+}
+
+func Test_WithFallback(t *testing.T) {
+	// the embedded Service of fruitsOnlyLayer is never set, so only Fruits can be called on it
+	svc, err := WithFallback[Service](&fruitsOnlyLayer{}, &LayerA{})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Fig]" {
+		t.Fatalf("expected [Fig], got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Artichoke]" {
+		t.Fatalf("expected the fallback to handle Veggies, got %v", svc.Veggies())
+	}
+
+	if _, err := WithFallback[Service](&LayerD{}, nil); err == nil {
+		t.Fatalf("expected an error for a nil fallback")
+	}
+}
+
+// fruitsOnlyLayer is a partial implementation of Service that only declares Fruits.
+type fruitsOnlyLayer struct{ Service }
+
+func (*fruitsOnlyLayer) Fruits() []string { return []string{"Fig"} }
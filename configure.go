@@ -0,0 +1,30 @@
+package cake
+
+// Configurable is implemented by layers that accept a shared configuration value of type C when
+// they are wired by LayeredConfigured.
+type Configurable[C interface{}] interface {
+	Configure(C)
+}
+
+// LayeredConfigured is like Layered, but after the cake is wired it calls Configure(cfg) on each
+// layer that implements Configurable[C]. Layers that don't implement it are left untouched. This
+// is useful for distributing a shared configuration to every layer without passing it to each
+// layer's constructor.
+func LayeredConfigured[T interface{}, C interface{}](cfg C, base T, layers ...T) (T, error) {
+	res, err := Layered[T](base, layers...)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for _, layer := range layers {
+		if _, ok := getLayerValue(layer); !ok {
+			continue
+		}
+
+		if configurable, ok := unwrapStandIn(layer).(Configurable[C]); ok {
+			configurable.Configure(cfg)
+		}
+	}
+
+	return res, nil
+}
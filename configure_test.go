@@ -0,0 +1,39 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+type fruitConfig struct {
+	Suffix string
+}
+
+type configuredLayer struct {
+	Service
+	cfg fruitConfig
+}
+
+func (l *configuredLayer) Configure(cfg fruitConfig) {
+	l.cfg = cfg
+}
+
+func (l *configuredLayer) Fruits() []string {
+	return append(l.Service.Fruits(), "Fig"+l.cfg.Suffix)
+}
+
+func Test_LayeredConfigured(t *testing.T) {
+	svc, err := LayeredConfigured[Service](fruitConfig{Suffix: "!"}, &LayerA{},
+		&configuredLayer{},
+		&LayerB{},
+		&configuredLayer{},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Fig!", "Banana", "Fig!"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+}
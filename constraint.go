@@ -0,0 +1,81 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Constrained can be implemented by layers that must be placed next to a layer of a specific type.
+// Layered validates the constraints of every layer after skipping nil and disabled layers, and
+// returns a *LayerError for the first constraint that is violated.
+type Constrained interface {
+	Constraints() []Constraint
+}
+
+type relation int
+
+const (
+	inside relation = iota
+	outside
+)
+
+// Constraint is a requirement on the neighbor of a layer. It is created with InsideOf or OutsideOf.
+type Constraint struct {
+	relation relation
+	typ      reflect.Type
+}
+
+// InsideOf requires a layer to be immediately inside a layer of type *L, that is for the layer of
+// type *L to be its outer neighbor.
+func InsideOf[L interface{}]() Constraint {
+	return Constraint{relation: inside, typ: reflect.TypeOf((*L)(nil))}
+}
+
+// OutsideOf requires a layer to be immediately outside a layer of type *L, that is for the layer of
+// type *L, which may be the base, to be its next layer.
+func OutsideOf[L interface{}]() Constraint {
+	return Constraint{relation: outside, typ: reflect.TypeOf((*L)(nil))}
+}
+
+func (c Constraint) String() string {
+	if c.relation == inside {
+		return fmt.Sprintf("immediately inside %s", c.typ)
+	}
+	return fmt.Sprintf("immediately outside %s", c.typ)
+}
+
+// validateConstraints checks the constraints of each of the valid layers against their neighbors.
+func validateConstraints[T interface{}](base T, valid []T, indices []int) error {
+	for i, layer := range valid {
+		inner := unwrapStandIn(layer)
+		constrained, ok := inner.(Constrained)
+		if !ok || !definesMethod(reflect.TypeOf(inner), "Constraints") {
+			continue
+		}
+
+		for _, c := range constrained.Constraints() {
+			var neighbor any
+			var position string
+			switch {
+			case c.relation == inside && i == 0:
+				position = "the outermost layer"
+			case c.relation == inside:
+				neighbor = unwrapStandIn(valid[i-1])
+				position = fmt.Sprintf("inside %T", neighbor)
+			case i == len(valid)-1:
+				neighbor = base
+				position = fmt.Sprintf("outside the base %T", neighbor)
+			default:
+				neighbor = unwrapStandIn(valid[i+1])
+				position = fmt.Sprintf("outside %T", neighbor)
+			}
+
+			if neighbor != nil && reflect.TypeOf(neighbor) == c.typ {
+				continue
+			}
+
+			return &LayerError{Index: indices[i], Layer: layer, Err: fmt.Errorf("must be %s, but is %s", c, position)}
+		}
+	}
+	return nil
+}
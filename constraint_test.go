@@ -0,0 +1,67 @@
+package cake
+
+import (
+	"errors"
+	"testing"
+)
+
+// auditLayer must be placed right inside of LayerB.
+type auditLayer struct{ Service }
+
+func (l *auditLayer) Constraints() []Constraint {
+	return []Constraint{InsideOf[LayerB]()}
+}
+
+// cacheLayer must be placed right over LayerA.
+type cacheLayer struct{ Service }
+
+func (l *cacheLayer) Constraints() []Constraint {
+	return []Constraint{OutsideOf[LayerA]()}
+}
+
+func Test_Constrained(t *testing.T) {
+	testTable := map[string]struct {
+		layers        []Service
+		expectedErr   string
+		expectedIndex int
+	}{
+		"Satisfied constraints": {
+			layers: []Service{&LayerB{}, nil, &auditLayer{}, &LayerC{}, &cacheLayer{}},
+		},
+		"Wrong outer neighbor": {
+			layers:        []Service{&LayerC{}, &auditLayer{}},
+			expectedErr:   "layer '*cake.auditLayer': must be immediately inside *cake.LayerB, but is inside *cake.LayerC",
+			expectedIndex: 1,
+		},
+		"No outer neighbor": {
+			layers:        []Service{&auditLayer{}, &LayerB{}},
+			expectedErr:   "layer '*cake.auditLayer': must be immediately inside *cake.LayerB, but is the outermost layer",
+			expectedIndex: 0,
+		},
+		"Wrong inner neighbor": {
+			layers:        []Service{&cacheLayer{}, &LayerC{}},
+			expectedErr:   "layer '*cake.cacheLayer': must be immediately outside *cake.LayerA, but is outside *cake.LayerC",
+			expectedIndex: 0,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := Layered[Service](&LayerA{}, testCase.layers...)
+			if testCase.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("failed to layer cake: %+v", err)
+				}
+				return
+			}
+
+			var layerErr *LayerError
+			if !errors.As(err, &layerErr) || err.Error() != testCase.expectedErr {
+				t.Fatalf("expected error %q, got %v", testCase.expectedErr, err)
+			}
+
+			if layerErr.Index != testCase.expectedIndex {
+				t.Fatalf("expected index %d, got %d", testCase.expectedIndex, layerErr.Index)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LayeredFromContainer is like Layered, but resolves each layer from a dependency injection
+// container, such as one built with dig or fx. resolve is called with each type in order, from the
+// outermost layer inward, and must return a value of that type. It returns an error naming the type
+// if it cannot be resolved, or if the resolved value does not implement T.
+func LayeredFromContainer[T interface{}](base T, resolve func(reflect.Type) (any, error), order []reflect.Type) (T, error) {
+	var layers = make([]T, 0, len(order))
+	for _, typ := range order {
+		value, err := resolve(typ)
+		if err != nil {
+			return *new(T), fmt.Errorf("failed to resolve %s: %w", typ, err)
+		}
+
+		layer, ok := value.(T)
+		if !ok {
+			return *new(T), fmt.Errorf("resolved %s: %s", typ, WhyNotAssignable[T](value))
+		}
+		layers = append(layers, layer)
+	}
+
+	return Layered[T](base, layers...)
+}
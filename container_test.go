@@ -0,0 +1,53 @@
+package cake
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_LayeredFromContainer(t *testing.T) {
+	var singletons = map[reflect.Type]any{
+		reflect.TypeOf(&LayerB{}): &LayerB{},
+		reflect.TypeOf(&LayerD{}): &LayerD{},
+		reflect.TypeOf(42):        42,
+	}
+	var resolve = func(typ reflect.Type) (any, error) {
+		if value, ok := singletons[typ]; ok {
+			return value, nil
+		}
+		return nil, errors.New("not provided")
+	}
+
+	svc, err := LayeredFromContainer[Service](&LayerA{}, resolve, []reflect.Type{reflect.TypeOf(&LayerD{}), reflect.TypeOf(&LayerB{})})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if Describe(svc) != "LayerD -> LayerB -> LayerA" || svc != singletons[reflect.TypeOf(&LayerD{})] {
+		t.Fatalf("expected the resolved singletons in order, got %s", Describe(svc))
+	}
+
+	testTable := map[string]struct {
+		order       []reflect.Type
+		expectedErr string
+	}{
+		"Type that cannot be resolved": {
+			order:       []reflect.Type{reflect.TypeOf(&LayerB{}), reflect.TypeOf(&LayerC{})},
+			expectedErr: "failed to resolve *cake.LayerC: not provided",
+		},
+		"Type that does not implement T": {
+			order:       []reflect.Type{reflect.TypeOf(42)},
+			expectedErr: "resolved int: int does not implement cake.Service: missing method Fruits; missing method Veggies",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := LayeredFromContainer[Service](&LayerA{}, resolve, testCase.order)
+			if err == nil || err.Error() != testCase.expectedErr {
+				t.Fatalf("expected error %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,30 @@
+package cake
+
+// DepsInjectable is implemented by layers that accept a shared bundle of dependencies of type D,
+// such as a semaphore or a rate limiter, when they are wired by LayeredDeps.
+type DepsInjectable[D interface{}] interface {
+	InjectDeps(D)
+}
+
+// LayeredDeps is like Layered, but after the cake is wired it calls InjectDeps(deps) on each layer
+// that implements DepsInjectable[D]. Layers that don't implement it are left untouched. Unlike the
+// config passed to LayeredConfigured, deps is meant to hold shared resources that layers coordinate
+// through.
+func LayeredDeps[T interface{}, D interface{}](deps D, base T, layers ...T) (T, error) {
+	res, err := Layered[T](base, layers...)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for _, layer := range layers {
+		if _, ok := getLayerValue(layer); !ok {
+			continue
+		}
+
+		if injectable, ok := unwrapStandIn(layer).(DepsInjectable[D]); ok {
+			injectable.InjectDeps(deps)
+		}
+	}
+
+	return res, nil
+}
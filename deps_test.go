@@ -0,0 +1,63 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+// limiter is a semaphore shared by the layers of a cake.
+type limiter struct{ slots chan struct{} }
+
+func (l *limiter) tryAcquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *limiter) release() { <-l.slots }
+
+type limiterDeps struct {
+	Limiter *limiter
+}
+
+// limitedLayer only adds its fruit if it can acquire a slot of the shared limiter.
+type limitedLayer struct {
+	Service
+	fruit string
+	deps  limiterDeps
+}
+
+func (l *limitedLayer) InjectDeps(deps limiterDeps) { l.deps = deps }
+
+func (l *limitedLayer) Fruits() []string {
+	if !l.deps.Limiter.tryAcquire() {
+		return append(l.Service.Fruits(), l.fruit+" (limited)")
+	}
+	defer l.deps.Limiter.release()
+	return append(l.Service.Fruits(), l.fruit)
+}
+
+func Test_LayeredDeps(t *testing.T) {
+	deps := limiterDeps{Limiter: &limiter{slots: make(chan struct{}, 1)}}
+	svc, err := LayeredDeps[Service](deps, &LayerA{},
+		&limitedLayer{fruit: "Kiwi"},
+		&LayerB{},
+		&limitedLayer{fruit: "Lime"},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	// the outer layer holds the only slot while it calls the inner one
+	expectedFruits := []string{"Apple", "Lime (limited)", "Banana", "Kiwi"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	if n := len(deps.Limiter.slots); n != 0 {
+		t.Fatalf("expected every slot to be released, got %d held", n)
+	}
+}
@@ -0,0 +1,90 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WhyNotAssignable explains why layer cannot be used as a T, e.g. because its methods are defined
+// on a pointer receiver and a value was passed instead of a pointer. It returns an empty string if
+// layer can be used as a T.
+func WhyNotAssignable[T interface{}](layer any) string {
+	var interfaceType = TypeOf[T]()
+
+	t := reflect.TypeOf(layer)
+	if t == nil {
+		return "layer is nil"
+	} else if t.Implements(interfaceType) {
+		return ""
+	}
+
+	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(interfaceType) {
+		return fmt.Sprintf("methods of %s are defined on a pointer receiver; pass &value", t)
+	}
+
+	var reasons []string
+	for i := 0; i < interfaceType.NumMethod(); i++ {
+		want := interfaceType.Method(i)
+		got, ok := t.MethodByName(want.Name)
+		if !ok {
+			if t.Kind() != reflect.Ptr {
+				if _, ok := reflect.PointerTo(t).MethodByName(want.Name); ok {
+					reasons = append(reasons, fmt.Sprintf("method %s is defined on a pointer receiver", want.Name))
+					continue
+				}
+			}
+			reasons = append(reasons, fmt.Sprintf("missing method %s", want.Name))
+			continue
+		}
+
+		// drop the receiver from the method's type so it can be compared to the interface method
+		var in []reflect.Type
+		for j := 1; j < got.Type.NumIn(); j++ {
+			in = append(in, got.Type.In(j))
+		}
+		var out []reflect.Type
+		for j := 0; j < got.Type.NumOut(); j++ {
+			out = append(out, got.Type.Out(j))
+		}
+		if sig := reflect.FuncOf(in, out, got.Type.IsVariadic()); sig != want.Type {
+			reasons = append(reasons, fmt.Sprintf("method %s has signature %s, expected %s", want.Name, sig, want.Type))
+		}
+	}
+
+	return fmt.Sprintf("%s does not implement %s: %s", t, interfaceType, strings.Join(reasons, "; "))
+}
+
+// CanWire reports whether Layered could wire layer into a cake of T, using the same resolution as
+// Layered. If it couldn't, the reason is returned as well, e.g. because layer is not a pointer to a
+// struct or has no settable field for the next layer. A layer implementing NextSetter can always be
+// wired, as long as every other layer of the cake implements it too.
+func CanWire[T interface{}](layer T) (bool, string) {
+	if _, ok := any(layer).(NextSetter[T]); ok {
+		return true, ""
+	}
+
+	if _, err := newOptions(nil).inspectLayer(layer, TypeOf[T]()); err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// Compatible reports whether inner can be wired as the next layer of outer, that is whether the
+// concrete type of inner can be assigned to the delegate field Layered would resolve for outer. If it
+// can't, the reason is returned as well. This catches layers written against different versions of
+// an interface before they are wired.
+func Compatible[T interface{}](outer T, inner T) (bool, string) {
+	info, err := newOptions(nil).inspectLayer(outer, TypeOf[T]())
+	if err != nil {
+		return false, fmt.Sprintf("outer layer '%T': %v", outer, err)
+	}
+
+	innerType := reflect.TypeOf(inner)
+	if innerType == nil {
+		return false, "inner layer is nil"
+	} else if !innerType.AssignableTo(info.field.Type()) {
+		return false, fmt.Sprintf("%s cannot be assigned to field %s of type %s", innerType, info.name, info.field.Type())
+	}
+	return true, ""
+}
@@ -0,0 +1,157 @@
+package cake
+
+import "testing"
+
+type wrongSignatureLayer struct{}
+
+func (l *wrongSignatureLayer) Fruits() string    { return "" }
+func (l *wrongSignatureLayer) Veggies() []string { return nil }
+
+func Test_WhyNotAssignable(t *testing.T) {
+	testTable := map[string]struct {
+		layer    any
+		expected string
+	}{
+		"Assignable": {
+			layer:    &LayerB{},
+			expected: "",
+		},
+		"Nil": {
+			layer:    nil,
+			expected: "layer is nil",
+		},
+		"Value of a type with pointer receivers": {
+			layer:    LayerB{},
+			expected: "methods of cake.LayerB are defined on a pointer receiver; pass &value",
+		},
+		"Missing method": {
+			layer:    fruitStand{},
+			expected: "cake.fruitStand does not implement cake.Service: missing method Veggies",
+		},
+		"Wrong signature": {
+			layer:    &wrongSignatureLayer{},
+			expected: "*cake.wrongSignatureLayer does not implement cake.Service: method Fruits has signature func() string, expected func() []string",
+		},
+		"Unrelated type": {
+			layer:    42,
+			expected: "int does not implement cake.Service: missing method Fruits; missing method Veggies",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			if got := WhyNotAssignable[Service](testCase.layer); got != testCase.expected {
+				t.Fatalf("expected %q, got %q", testCase.expected, got)
+			}
+		})
+	}
+}
+
+// hiddenLayer tags an unexported field as its delegate, which cannot be set by reflection.
+type hiddenLayer struct {
+	next Service `cake:"delegate"`
+}
+
+func (l *hiddenLayer) Fruits() []string  { return l.next.Fruits() }
+func (l *hiddenLayer) Veggies() []string { return l.next.Veggies() }
+
+func Test_CanWire(t *testing.T) {
+	var one = intLayer(1)
+
+	testTable := map[string]struct {
+		layer          Service
+		expectedOK     bool
+		expectedReason string
+	}{
+		"Layer embedding the interface": {
+			layer:      &LayerB{},
+			expectedOK: true,
+		},
+		"Layer implementing NextSetter": {
+			layer:      &setterLayer{},
+			expectedOK: true,
+		},
+		"Nil layer": {
+			layer:          nil,
+			expectedReason: "layer must be a non-nil pointer to a struct, got <nil>",
+		},
+		"Non-pointer layer": {
+			layer:          valueLayer{},
+			expectedReason: "layer must be a non-nil pointer to a struct, got cake.valueLayer",
+		},
+		"Pointer to a non-struct": {
+			layer:          &one,
+			expectedReason: "layer must be a non-nil pointer to a struct, got *cake.intLayer",
+		},
+		"Missing field": {
+			layer:          &brokenLayer{},
+			expectedReason: "field Service not found",
+		},
+		"Unexported field": {
+			layer:          &hiddenLayer{},
+			expectedReason: "field next cannot be set",
+		},
+		"Field of the wrong type": {
+			layer:          &stringFieldLayer{},
+			expectedReason: "field type mismatch: field Service is of type string, which cannot hold a cake.Service",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			ok, reason := CanWire(testCase.layer)
+			if ok != testCase.expectedOK || reason != testCase.expectedReason {
+				t.Fatalf("expected (%t, %q), got (%t, %q)", testCase.expectedOK, testCase.expectedReason, ok, reason)
+			}
+		})
+	}
+}
+
+// v2DelegateLayer is a Service layer whose delegate must also implement ServiceV2.
+type v2DelegateLayer struct {
+	Next ServiceV2 `cake:"delegate"`
+}
+
+func (l *v2DelegateLayer) Fruits() []string  { return l.Next.Fruits() }
+func (l *v2DelegateLayer) Veggies() []string { return append(l.Next.Veggies(), l.Next.Herbs()...) }
+
+func Test_Compatible(t *testing.T) {
+	testTable := map[string]struct {
+		outer          Service
+		inner          Service
+		expectedOK     bool
+		expectedReason string
+	}{
+		"Compatible layers": {
+			outer:      &LayerB{},
+			inner:      &LayerA{},
+			expectedOK: true,
+		},
+		"Inner layer implements the delegate's interface": {
+			outer:      &v2DelegateLayer{},
+			inner:      &herbLayerV2{},
+			expectedOK: true,
+		},
+		"Inner layer does not implement the delegate's interface": {
+			outer:          &v2DelegateLayer{},
+			inner:          &LayerA{},
+			expectedReason: "*cake.LayerA cannot be assigned to field Next of type cake.ServiceV2",
+		},
+		"Outer layer cannot be wired": {
+			outer:          &brokenLayer{},
+			inner:          &LayerA{},
+			expectedReason: "outer layer '*cake.brokenLayer': field Service not found",
+		},
+		"Nil inner layer": {
+			outer:          &LayerB{},
+			inner:          nil,
+			expectedReason: "inner layer is nil",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			ok, reason := Compatible(testCase.outer, testCase.inner)
+			if ok != testCase.expectedOK || reason != testCase.expectedReason {
+				t.Fatalf("expected (%t, %q), got (%t, %q)", testCase.expectedOK, testCase.expectedReason, ok, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package cake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ToDOT returns the structure of chain as a Graphviz DOT digraph. Each value in chain is a node
+// labeled with the name of its concrete type, with an edge to its next layer. The base is drawn as
+// a box. The output can be rendered with e.g. `dot -Tsvg`.
+func ToDOT[T interface{}](chain T) string {
+	var names []string
+	WalkLayers(chain, func(layer T, _ int) bool {
+		names = append(names, layerName(layer))
+		return true
+	})
+
+	var b strings.Builder
+	b.WriteString("digraph cake {\n")
+	for i, name := range names {
+		if i == len(names)-1 {
+			fmt.Fprintf(&b, "\tn%d [label=%q, shape=box];\n", i, name)
+		} else {
+			fmt.Fprintf(&b, "\tn%d [label=%q];\n", i, name)
+		}
+	}
+	for i := 1; i < len(names); i++ {
+		fmt.Fprintf(&b, "\tn%d -> n%d;\n", i-1, i)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,26 @@
+package cake
+
+import (
+	"testing"
+)
+
+func Test_ToDOT(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected := `digraph cake {
+	n0 [label="LayerB"];
+	n1 [label="LayerC"];
+	n2 [label="LayerD"];
+	n3 [label="LayerA", shape=box];
+	n0 -> n1;
+	n1 -> n2;
+	n2 -> n3;
+}
+`
+	if dot := ToDOT(chain); dot != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, dot)
+	}
+}
@@ -0,0 +1,97 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LayeredFromStruct is like Layered, but collects the layers from the fields of the struct (or
+// pointer to a struct) cfg that are tagged with `cake:"layer"`. The layers are sorted by the order
+// option of their tag, e.g. `cake:"layer,order=10"`, from lowest to highest, so the field with the
+// lowest order becomes the outermost layer. Fields without an order sort as 0, and fields with the
+// same order keep the order in which they are declared. Nil fields are skipped, but every tagged
+// field must be of a type that implements T. This is useful for declaring the composition of a
+// cake in a single configuration struct.
+func LayeredFromStruct[T interface{}](base T, cfg any) (T, error) {
+	var interfaceType = TypeOf[T]()
+
+	val := reflect.ValueOf(cfg)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return *new(T), fmt.Errorf("cfg must be a struct or a pointer to a struct, got %T", cfg)
+	}
+
+	type entry struct {
+		layer T
+		order int
+	}
+
+	var entries []entry
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		tag, ok := field.Tag.Lookup("cake")
+		if !ok {
+			continue
+		}
+
+		order, err := parseLayerTag(tag)
+		if err != nil {
+			return *new(T), fmt.Errorf("field %s: %w", field.Name, err)
+		} else if !field.IsExported() {
+			return *new(T), fmt.Errorf("field %s: must be exported", field.Name)
+		} else if !implements(field.Type, interfaceType) {
+			return *new(T), fmt.Errorf("field %s: %s does not implement %s", field.Name, field.Type, interfaceType)
+		}
+
+		layer, _ := val.Field(i).Interface().(T)
+		entries = append(entries, entry{layer: layer, order: order})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].order < entries[j].order
+	})
+
+	var layers = make([]T, 0, len(entries))
+	for _, e := range entries {
+		layers = append(layers, e.layer)
+	}
+
+	return Layered[T](base, layers...)
+}
+
+// implements reports whether a field of fieldType always holds a value of interfaceType, whether or
+// not the field is set.
+func implements(fieldType reflect.Type, interfaceType reflect.Type) bool {
+	if fieldType.AssignableTo(interfaceType) {
+		return true
+	}
+	return interfaceType.Kind() == reflect.Interface && fieldType.Implements(interfaceType)
+}
+
+// parseLayerTag parses a `cake:"layer,order=N"` struct tag and returns its order.
+func parseLayerTag(tag string) (int, error) {
+	parts := strings.Split(tag, ",")
+	if parts[0] != "layer" {
+		return 0, fmt.Errorf("invalid cake tag %q", tag)
+	}
+
+	var order int
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		if key != "order" {
+			return 0, fmt.Errorf("unknown option %q in cake tag %q", key, tag)
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid order in cake tag %q: %w", tag, err)
+		}
+		order = n
+	}
+	return order, nil
+}
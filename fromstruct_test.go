@@ -0,0 +1,71 @@
+package cake
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func Test_LayeredFromStruct(t *testing.T) {
+	cfg := struct {
+		Logging   *LayerD `cake:"layer,order=30"`
+		Auth      *LayerB `cake:"layer,order=10"`
+		Debug     *LayerC `cake:"layer,order=20"`
+		Metrics   Service `cake:"layer,order=20"`
+		Untracked *LayerE
+	}{
+		Logging:   &LayerD{},
+		Auth:      &LayerB{},
+		Debug:     nil,
+		Metrics:   &LayerC{},
+		Untracked: &LayerE{},
+	}
+
+	svc, err := LayeredFromStruct[Service](&LayerA{}, &cfg)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected := "LayerB -> LayerC -> LayerD -> LayerA"
+	if desc := Describe(svc); desc != expected {
+		t.Fatalf("expected %q, got %q", expected, desc)
+	}
+}
+
+func Test_LayeredFromStruct_Errors(t *testing.T) {
+	testTable := map[string]struct {
+		cfg         any
+		expectedErr string
+	}{
+		"Not a struct": {
+			cfg:         42,
+			expectedErr: "cfg must be a struct",
+		},
+		"Invalid order": {
+			cfg: struct {
+				Auth *LayerB `cake:"layer,order=first"`
+			}{},
+			expectedErr: "invalid order",
+		},
+		"Field does not implement the interface": {
+			cfg: struct {
+				Name string `cake:"layer"`
+			}{Name: "auth"},
+			expectedErr: "field Name: string does not implement cake.Service",
+		},
+		"Nil field does not implement the interface": {
+			cfg: struct {
+				Closer io.Closer `cake:"layer"`
+			}{},
+			expectedErr: "field Closer: io.Closer does not implement cake.Service",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := LayeredFromStruct[Service](&LayerA{}, testCase.cfg)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+func FuzzLayered(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{2, 2, 2})
+	f.Add([]byte{0, 2, 1, 2, 0})
+	f.Add([]byte{1, 1, 0})
+
+	f.Fuzz(func(t *testing.T, arrangement []byte) {
+		if len(arrangement) > 64 {
+			arrangement = arrangement[:64]
+		}
+
+		// each byte picks a nil interface, a typed nil pointer or a valid layer
+		var base = &LayerA{}
+		var layers = make([]Service, len(arrangement))
+		var valid []*fruitLayer
+		for i, b := range arrangement {
+			switch b % 3 {
+			case 0:
+				layers[i] = nil
+			case 1:
+				layers[i] = (*fruitLayer)(nil)
+			case 2:
+				layer := &fruitLayer{fruit: fmt.Sprint(i)}
+				layers[i] = layer
+				valid = append(valid, layer)
+			}
+		}
+
+		svc, err := Layered[Service](base, layers...)
+		if err != nil {
+			t.Fatalf("failed to layer cake: %+v", err)
+		}
+
+		if len(valid) == 0 {
+			if svc != Service(base) {
+				t.Fatalf("expected the base to be returned without valid layers, got %T", svc)
+			}
+			return
+		} else if svc != Service(valid[0]) {
+			t.Fatalf("expected the outermost valid layer to be returned, got %v", svc)
+		}
+
+		var expectedFruits = []string{"Apple"}
+		for i, layer := range valid {
+			var next Service = base
+			if i < len(valid)-1 {
+				next = valid[i+1]
+			}
+			if layer.Service != next {
+				t.Fatalf("expected layer %s to delegate to %v, got %v", layer.fruit, next, layer.Service)
+			}
+			expectedFruits = append(expectedFruits, valid[len(valid)-1-i].fruit)
+		}
+
+		if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+			t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package cake
+
+import (
+	"errors"
+	"testing"
+)
+
+// Repository is a generic interface, layered here as Repository[User].
+type Repository[E any] interface {
+	Get(id string) (E, error)
+}
+
+type User struct {
+	ID   string
+	Name string
+}
+
+var errNotFound = errors.New("not found")
+
+type userRepo struct{ users map[string]User }
+
+func (r *userRepo) Get(id string) (User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return User{}, errNotFound
+}
+
+// upperLayer upper-cases the first letter of the name of every user.
+type upperLayer struct{ Repository[User] }
+
+func (l *upperLayer) Get(id string) (User, error) {
+	user, err := l.Repository.Get(id)
+	if err == nil && user.Name != "" && user.Name[0] >= 'a' && user.Name[0] <= 'z' {
+		user.Name = string(user.Name[0]-'a'+'A') + user.Name[1:]
+	}
+	return user, err
+}
+
+// guestLayer returns a guest for users that don't exist. It also has a second field of the same
+// interface type, so its delegate can only be resolved by the name of the embedded field.
+type guestLayer struct {
+	Repository[User]
+	Audit Repository[User]
+}
+
+func (l *guestLayer) Get(id string) (User, error) {
+	user, err := l.Repository.Get(id)
+	if errors.Is(err, errNotFound) {
+		return User{ID: id, Name: "guest"}, nil
+	}
+	return user, err
+}
+
+func Test_Layered_GenericInterface(t *testing.T) {
+	repo, err := Layered[Repository[User]](&userRepo{users: map[string]User{"1": {ID: "1", Name: "ada"}}},
+		&upperLayer{},
+		&guestLayer{},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if user, err := repo.Get("1"); err != nil || user.Name != "Ada" {
+		t.Fatalf("expected Ada, got %+v (%v)", user, err)
+	}
+
+	if user, err := repo.Get("2"); err != nil || user.Name != "Guest" {
+		t.Fatalf("expected Guest, got %+v (%v)", user, err)
+	}
+
+	if Describe(repo) != "upperLayer -> guestLayer -> userRepo" {
+		t.Fatalf("expected upperLayer -> guestLayer -> userRepo, got %s", Describe(repo))
+	}
+}
@@ -0,0 +1,21 @@
+package cake
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goid returns the id of the calling goroutine, parsed from the header of its stack trace. Go does
+// not expose goroutine ids on purpose, so this is only used to keep per-call state in proxies that
+// cannot be given a context.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,43 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Group is a named, ordered set of layers that belong to the same concern, such as "security" or
+// "observability".
+type Group[T interface{}] struct {
+	Name   string
+	Layers []T
+}
+
+// NewGroup returns a Group with the given name and layers.
+func NewGroup[T interface{}](name string, layers ...T) Group[T] {
+	return Group[T]{Name: name, Layers: layers}
+}
+
+// LayeredGroups is like Layered, but takes the layers as a list of groups. The layers of all groups
+// are flattened in order, so the first layer of the first group is the outermost layer. Errors name
+// both the group and the layer that could not be wired.
+func LayeredGroups[T interface{}](base T, groups ...Group[T]) (T, error) {
+	var layers []T
+	var owners []string
+	for _, group := range groups {
+		layers = append(layers, group.Layers...)
+		for range group.Layers {
+			owners = append(owners, group.Name)
+		}
+	}
+
+	res, err := Layered[T](base, layers...)
+	if err != nil {
+		var layerErr *LayerError
+		if errors.As(err, &layerErr) {
+			return *new(T), fmt.Errorf("group %q: %w", owners[layerErr.Index], err)
+		}
+		return *new(T), err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,38 @@
+package cake
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_LayeredGroups(t *testing.T) {
+	svc, err := LayeredGroups[Service](&LayerA{},
+		NewGroup[Service]("security", &LayerB{}, If(false, &LayerE{})),
+		NewGroup[Service]("observability", &LayerC{}, &LayerD{}),
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected := "LayerB -> LayerC -> LayerD -> LayerA"
+	if desc := Describe(svc); desc != expected {
+		t.Fatalf("expected %q, got %q", expected, desc)
+	}
+}
+
+func Test_LayeredGroups_Error(t *testing.T) {
+	_, err := LayeredGroups[Service](&LayerA{},
+		NewGroup[Service]("security", &LayerB{}),
+		NewGroup[Service]("observability", &LayerC{}, &brokenLayer{}),
+	)
+
+	var layerErr *LayerError
+	if !errors.As(err, &layerErr) {
+		t.Fatalf("expected a LayerError, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), `group "observability"`) || !strings.Contains(err.Error(), "brokenLayer") {
+		t.Fatalf("expected the error to name the group and the layer, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package cake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnhealthy is returned by LayeredHealthy when a layer fails its health check.
+var ErrUnhealthy = errors.New("layer is unhealthy")
+
+// HealthChecker can be implemented by layers that can tell whether they are ready to serve, e.g.
+// by pinging a remote dependency.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// LayeredHealthy is like Layered, but first calls HealthCheck on every layer that declares it
+// itself, from the outermost inward. If a health check fails, the cake is not wired and a
+// *LayerError wrapping ErrUnhealthy and the error of the health check is returned. Layers that
+// don't declare HealthCheck are not checked.
+func LayeredHealthy[T interface{}](ctx context.Context, base T, layers ...T) (T, error) {
+	for i, layer := range layers {
+		if _, ok := getLayerValue(layer); !ok {
+			continue
+		}
+
+		// a HealthCheck promoted from the embedded next layer would call into a layer that
+		// isn't wired yet.
+		inner := unwrapStandIn(layer)
+		checker, ok := inner.(HealthChecker)
+		if !ok || !definesMethod(reflect.TypeOf(inner), "HealthCheck") {
+			continue
+		}
+
+		if err := checker.HealthCheck(ctx); err != nil {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: fmt.Errorf("%w: %w", ErrUnhealthy, err)}
+		}
+	}
+
+	return Layered[T](base, layers...)
+}
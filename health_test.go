@@ -0,0 +1,54 @@
+package cake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// probedLayer fails its health check with err.
+type probedLayer struct {
+	Service
+	err     error
+	checked bool
+}
+
+func (l *probedLayer) HealthCheck(context.Context) error {
+	l.checked = true
+	return l.err
+}
+
+func Test_LayeredHealthy(t *testing.T) {
+	healthy := &probedLayer{}
+	svc, err := LayeredHealthy[Service](context.Background(), &LayerA{}, &LayerB{}, healthy, nil)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if !healthy.checked {
+		t.Fatalf("expected the health check to be called")
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	errDown := errors.New("connection refused")
+	unhealthy := &probedLayer{err: errDown}
+	outer := &LayerB{}
+	_, err = LayeredHealthy[Service](context.Background(), &LayerA{}, outer, unhealthy)
+
+	var layerErr *LayerError
+	if !errors.Is(err, ErrUnhealthy) || !errors.Is(err, errDown) || !errors.As(err, &layerErr) || layerErr.Index != 1 {
+		t.Fatalf("expected an unhealthy LayerError for layer 1, got %v", err)
+	}
+
+	if err.Error() != "layer '*cake.probedLayer': layer is unhealthy: connection refused" {
+		t.Fatalf("expected the error to name the unhealthy layer, got %q", err)
+	}
+
+	if outer.Service != nil {
+		t.Fatalf("expected the build to abort before wiring, got %T", outer.Service)
+	}
+}
@@ -0,0 +1,57 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Identified can be implemented by layers to give each instance an ID. This distinguishes layers of
+// the same type that appear more than once in a chain, e.g. two retry layers at different depths, so
+// that they can be targeted with FindByID, RemoveByID and ReplaceByID.
+type Identified interface {
+	CakeID() string
+}
+
+// hasID reports whether layer declares CakeID itself and returns id.
+func hasID(layer any, id string) bool {
+	layer = unwrapStandIn(layer)
+	identified, ok := layer.(Identified)
+	return ok && definesMethod(reflect.TypeOf(layer), "CakeID") && identified.CakeID() == id
+}
+
+// FindByID returns the first value in chain, starting from the outermost layer and including the
+// base, whose CakeID is id.
+func FindByID[T interface{}](chain T, id string) (T, bool) {
+	var found T
+	var ok bool
+	WalkLayers(chain, func(layer T, _ int) bool {
+		if hasID(layer, id) {
+			found, ok = layer, true
+		}
+		return !ok
+	})
+	return found, ok
+}
+
+// RemoveByID removes every layer of chain whose CakeID is id, like RemoveMatching.
+func RemoveByID[T interface{}](chain T, id string) (T, error) {
+	return RemoveMatching(chain, func(layer T) bool { return hasID(layer, id) })
+}
+
+// ReplaceByID replaces the first layer of chain whose CakeID is id with replacement and wires the
+// layers around the base again. The layers are modified in place, and the new outermost layer is
+// returned. It returns an error if no layer has the ID. The base is never replaced.
+func ReplaceByID[T interface{}](chain T, id string, replacement T) (T, error) {
+	layers, base, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for i, layer := range layers {
+		if hasID(layer, id) {
+			layers[i] = replacement
+			return Layered[T](base, layers...)
+		}
+	}
+	return *new(T), fmt.Errorf("no layer with ID %q", id)
+}
@@ -0,0 +1,57 @@
+package cake
+
+import (
+	"testing"
+)
+
+// idLayer is a layer of which more than one instance can be in a chain.
+type idLayer struct {
+	Service
+	id string
+}
+
+func (l *idLayer) CakeID() string { return l.id }
+
+func Test_Identified(t *testing.T) {
+	var newChain = func() (Service, *idLayer, *idLayer) {
+		outer, inner := &idLayer{id: "outer"}, &idLayer{id: "inner"}
+		chain, err := Layered[Service](&LayerA{}, outer, &LayerB{}, inner)
+		if err != nil {
+			t.Fatalf("failed to layer cake: %+v", err)
+		}
+		return chain, outer, inner
+	}
+
+	chain, _, inner := newChain()
+	if found, ok := FindByID(chain, "inner"); !ok || found != Service(inner) {
+		t.Fatalf("expected to find the inner layer, got %v", found)
+	}
+
+	if _, ok := FindByID(chain, "missing"); ok {
+		t.Fatalf("expected no layer to be found")
+	}
+
+	chain, outer, _ := newChain()
+	chain, err := RemoveByID(chain, "inner")
+	if err != nil {
+		t.Fatalf("failed to remove layer: %+v", err)
+	}
+
+	if Describe(chain) != "idLayer -> LayerB -> LayerA" || chain != Service(outer) {
+		t.Fatalf("expected only the inner layer to be removed, got %s", Describe(chain))
+	}
+
+	chain, _, inner = newChain()
+	chain, err = ReplaceByID[Service](chain, "outer", &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to replace layer: %+v", err)
+	}
+
+	if Describe(chain) != "LayerD -> LayerB -> idLayer -> LayerA" || Layers(chain)[2] != Service(inner) {
+		t.Fatalf("expected only the outer layer to be replaced, got %s", Describe(chain))
+	}
+
+	if _, err := ReplaceByID[Service](chain, "outer", &LayerD{}); err == nil {
+		t.Fatalf("expected an error for a missing ID")
+	}
+}
@@ -0,0 +1,151 @@
+package cake
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// MethodSet returns the names of the methods of T in sorted order.
+func MethodSet[T interface{}]() []string {
+	var interfaceType = TypeOf[T]()
+	var names = make([]string, 0, interfaceType.NumMethod())
+	for i := 0; i < interfaceType.NumMethod(); i++ {
+		names = append(names, interfaceType.Method(i).Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inMethodSet reports whether name is in the sorted method set methods.
+func inMethodSet(methods []string, name string) bool {
+	i := sort.SearchStrings(methods, name)
+	return i < len(methods) && methods[i] == name
+}
+
+// definesMethod reports whether the concrete type t declares the named method itself, rather than
+// having it promoted from an embedded field. Promoted methods are compiler generated wrappers, and
+// so are the pointer receiver forms of value receiver methods, which is why both the pointer and
+// the value type are checked.
+func definesMethod(t reflect.Type, name string) bool {
+	if t == nil {
+		return false
+	}
+
+	if m, ok := t.MethodByName(name); ok && !isGenerated(m) {
+		return true
+	}
+	if t.Kind() == reflect.Ptr {
+		if m, ok := t.Elem().MethodByName(name); ok && !isGenerated(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenerated reports whether the method m is a wrapper generated by the compiler.
+func isGenerated(m reflect.Method) bool {
+	fn := runtime.FuncForPC(m.Func.Pointer())
+	if fn == nil {
+		return false
+	}
+	file, _ := fn.FileLine(fn.Entry())
+	return file == "<autogenerated>"
+}
+
+// OverrideCount returns the number of values in chain, including the base, whose concrete type
+// declares the given method itself instead of just forwarding it to the next layer through the
+// embedded interface. This is the number of implementations a call to the method passes through.
+// It returns 0 if T has no such method.
+func OverrideCount[T interface{}](chain T, method string) int {
+	if !inMethodSet(MethodSet[T](), method) {
+		return 0
+	}
+
+	var count int
+	WalkLayers(chain, func(layer T, _ int) bool {
+		if definesMethod(reflect.TypeOf(layer), method) {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// layerName returns the name of the concrete type of layer without its package or pointer
+// indirection, e.g. "loggingLayer" for a *loggingLayer.
+func layerName(layer any) string {
+	t := reflect.TypeOf(unwrapStandIn(layer))
+	if t == nil {
+		return "nil"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.Name()
+}
+
+// Coverage maps each method of T to the names of the layers in chain that declare the method
+// themselves. The names are ordered from the innermost layer outward, which is the order in which
+// the overrides are applied to the result of a call. The base is not included.
+func Coverage[T interface{}](chain T) map[string][]string {
+	var methods = MethodSet[T]()
+	var layers = Layers(chain)
+	var coverage = make(map[string][]string, len(methods))
+
+	for _, method := range methods {
+		coverage[method] = []string{}
+		for j := len(layers) - 1; j >= 0; j-- {
+			if definesMethod(reflect.TypeOf(layers[j]), method) {
+				coverage[method] = append(coverage[method], layerName(layers[j]))
+			}
+		}
+	}
+
+	return coverage
+}
+
+// Describe returns a human readable description of chain, listing the name of each layer from the
+// outermost inward and ending with the base, e.g. "authLayer -> loggingLayer -> baseLayer".
+func Describe[T interface{}](chain T) string {
+	return describe(chain, layerName)
+}
+
+// describe is like Describe, but names each value with name.
+func describe[T interface{}](chain T, name func(layer any) string) string {
+	var names []string
+	WalkLayers(chain, func(layer T, _ int) bool {
+		names = append(names, name(layer))
+		return true
+	})
+	return strings.Join(names, " -> ")
+}
+
+// ReachesBase reports whether a call to method on chain can reach the base, that is whether the
+// concrete type of the base declares the method itself. If it doesn't, the method is provided
+// entirely by the layers.
+//
+// This is an approximation: cake cannot analyze method bodies, so a layer that overrides the
+// method without calling its next layer still counts as reaching the base.
+func ReachesBase[T interface{}](chain T, method string) bool {
+	return definesMethod(reflect.TypeOf(Base(chain)), method)
+}
+
+// DeadLayers returns the names of the layers of chain that declare method themselves and whose
+// immediate outer neighbor declares it too, from the outermost inward. If the outer layer doesn't
+// call its next layer, the override of the inner one is dead code. This is purely advisory: as with
+// ReachesBase, cake cannot tell whether the outer layer calls through. The base is not included.
+func DeadLayers[T interface{}](chain T, method string) []string {
+	var layers = Layers(chain)
+	var dead []string
+	for i := 1; i < len(layers); i++ {
+		if definesMethod(reflect.TypeOf(layers[i-1]), method) && definesMethod(reflect.TypeOf(layers[i]), method) {
+			dead = append(dead, layerName(layers[i]))
+		}
+	}
+	return dead
+}
@@ -0,0 +1,160 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type valueLayer struct{ Service }
+
+func (l valueLayer) Fruits() []string { return []string{"Value"} }
+
+func Test_definesMethod(t *testing.T) {
+	testTable := map[string]struct {
+		typ      reflect.Type
+		method   string
+		expected bool
+	}{
+		"Pointer receiver method": {
+			typ:      reflect.TypeOf(&LayerD{}),
+			method:   "Fruits",
+			expected: true,
+		},
+		"Method promoted from the embedded interface": {
+			typ:      reflect.TypeOf(&LayerC{}),
+			method:   "Fruits",
+			expected: false,
+		},
+		"Value receiver method through a pointer": {
+			typ:      reflect.TypeOf(&valueLayer{}),
+			method:   "Fruits",
+			expected: true,
+		},
+		"Missing method": {
+			typ:      reflect.TypeOf(&LayerE{}),
+			method:   "Herbs",
+			expected: false,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			if got := definesMethod(testCase.typ, testCase.method); got != testCase.expected {
+				t.Fatalf("expected %t, got %t", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func Test_OverrideCount(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerE{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if count := OverrideCount(svc, "Fruits"); count != 3 {
+		t.Fatalf("expected 3 overrides of Fruits, got %d", count)
+	}
+
+	if count := OverrideCount(svc, "Veggies"); count != 4 {
+		t.Fatalf("expected 4 overrides of Veggies, got %d", count)
+	}
+}
+
+func Test_Coverage(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerE{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected := map[string][]string{
+		"Fruits":  {"LayerD", "LayerB"},
+		"Veggies": {"LayerD", "LayerC", "LayerB"},
+	}
+	if coverage := Coverage(svc); !reflect.DeepEqual(coverage, expected) {
+		t.Fatalf("expected %v, got %v", expected, coverage)
+	}
+}
+
+func Test_Describe(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, If(false, &LayerC{}), &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected := "LayerB -> LayerD -> LayerA"
+	if desc := Describe(svc); desc != expected {
+		t.Fatalf("expected %q, got %q", expected, desc)
+	}
+}
+
+// fruitBase only provides Fruits, Veggies is left to the layers.
+type fruitBase struct{ Service }
+
+func (fruitBase) Fruits() []string { return []string{"Fig"} }
+
+// kaleLayer provides Veggies without calling its next layer.
+type kaleLayer struct{ Service }
+
+func (*kaleLayer) Veggies() []string { return []string{"Kale"} }
+
+func Test_ReachesBase(t *testing.T) {
+	svc, err := Layered[Service](&fruitBase{}, &LayerB{}, &kaleLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if !ReachesBase(svc, "Fruits") {
+		t.Fatalf("expected Fruits to reach the base")
+	}
+
+	if ReachesBase(svc, "Veggies") {
+		t.Fatalf("expected Veggies not to reach the base")
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Kale Basil]" {
+		t.Fatalf("expected [Kale Basil], got %v", svc.Veggies())
+	}
+}
+
+func Test_DeadLayers(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerD{}, &LayerC{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	testTable := map[string]struct {
+		method   string
+		expected []string
+	}{
+		"Only adjacent overrides are flagged": {
+			method:   "Fruits",
+			expected: []string{"LayerD"},
+		},
+		"Every inner override of a run is flagged": {
+			method:   "Veggies",
+			expected: []string{"LayerD", "LayerC"},
+		},
+		"Unknown method": {
+			method:   "Herbs",
+			expected: nil,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			if dead := DeadLayers(svc, testCase.method); fmt.Sprint(dead) != fmt.Sprint(testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, dead)
+			}
+		})
+	}
+}
+
+func Test_MethodSet(t *testing.T) {
+	if methods := MethodSet[Service](); fmt.Sprint(methods) != "[Fruits Veggies]" {
+		t.Fatalf("expected [Fruits Veggies], got %v", methods)
+	}
+
+	if methods := MethodSet[Store](); fmt.Sprint(methods) != "[Get Len Put]" {
+		t.Fatalf("expected [Get Len Put], got %v", methods)
+	}
+}
@@ -1,11 +1,124 @@
 package cake
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"time"
 )
 
+// TypeOf returns the reflect.Type of T. When T is an interface type this is the interface type
+// itself rather than the type of a value stored in it, which makes it suitable for registering a
+// layered cake in a DI container. It is the same type cake uses to resolve the embedded field of
+// each layer.
+func TypeOf[T interface{}]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ErrInvalidBase is returned by Layered when base is a nil interface value, which cannot be set as
+// the next layer of the innermost layer, unless the innermost layer is Terminal.
+var ErrInvalidBase = errors.New("base must not be a nil interface value")
+
+// ErrEmptyInterface is returned by Layered when T is an interface without methods, such as any.
+// Every type implements such an interface, so there is no meaningful delegate field to resolve.
+var ErrEmptyInterface = errors.New("cannot layer an interface without methods")
+
+// LayerError is returned by Layered when a layer cannot be wired.
+type LayerError struct {
+	// Index is the position of the layer in the list of layers passed to Layered.
+	Index int
+	// Layer is the layer that could not be wired.
+	Layer any
+	// Name is the name the layer was given with Name, if any.
+	Name string
+	// Err describes why the layer could not be wired.
+	Err error
+}
+
+func (e *LayerError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("layer '%s': %v", e.Name, e.Err)
+	}
+	return fmt.Sprintf("layer '%T': %v", e.Layer, e.Err)
+}
+
+func (e *LayerError) Unwrap() error {
+	return e.Err
+}
+
+// NextSetter can be implemented by layers to set their next layer themselves. When every layer
+// passed to Layered implements NextSetter, the layers are wired by calling SetNext instead of
+// setting their embedded field with reflection.
+//
+// cake cannot follow the next layer of a layer that only holds it in an unexported field, so a
+// chain wired through SetNext cannot be inspected or mutated: Layers, Base and Describe report the
+// outermost layer as the base, and the helpers that rewire or wrap the layers of a chain, such as
+// RemoveMatching or WithLayerTiming, return ErrNotWalkable.
+type NextSetter[T interface{}] interface {
+	SetNext(next T)
+}
+
+// nextSetters returns layers as NextSetters, or false if any of them is not a NextSetter.
+func nextSetters[T interface{}](layers []T) ([]NextSetter[T], bool) {
+	var setters = make([]NextSetter[T], 0, len(layers))
+	for _, layer := range layers {
+		setter, ok := any(layer).(NextSetter[T])
+		if !ok {
+			return nil, false
+		}
+		setters = append(setters, setter)
+	}
+	return setters, true
+}
+
+// DepthAware can be implemented by layers that need to know their position in a cake. Once the
+// cake is wired, Layered calls SetDepth on each of these layers with 0 for the outermost layer,
+// increasing inward.
+type DepthAware interface {
+	SetDepth(depth int)
+}
+
+// Enabler can be implemented by layers that decide for themselves whether they should be part of
+// a cake. A layer whose Enabled method returns false is skipped as if it were nil.
+type Enabler interface {
+	Enabled() bool
+}
+
+// Terminal can be implemented by layers that implement every method of the interface themselves
+// and never call their next layer. When the innermost layer of a cake is Terminal, Layered accepts
+// a nil base and leaves the delegate of the layer nil, and Verify treats the nil delegate as
+// intentional.
+type Terminal interface {
+	Terminal()
+}
+
+// isTerminal reports whether layer declares the Terminal marker method itself.
+func isTerminal(layer any) bool {
+	layer = unwrapStandIn(layer)
+	_, ok := layer.(Terminal)
+	return ok && definesMethod(reflect.TypeOf(layer), "Terminal")
+}
+
+// Skippable can be implemented by layers that want to be notified when they are skipped by a
+// WithSkip func or by their own Enabled method, e.g. to release resources they allocated. OnSkip is
+// not called for nil layers.
+type Skippable interface {
+	OnSkip()
+}
+
+// notifySkipped calls OnSkip on layer if it is a non-nil pointer that declares the method itself.
+func notifySkipped(layer any, val reflect.Value) {
+	if !val.IsValid() || val.Kind() != reflect.Ptr || val.IsNil() {
+		return
+	}
+
+	layer = unwrapStandIn(layer)
+	if skippable, ok := layer.(Skippable); ok && definesMethod(reflect.TypeOf(layer), "OnSkip") {
+		skippable.OnSkip()
+	}
+}
+
 func getLayerValue(layer any) (reflect.Value, bool) {
 	if layer == nil {
 		return reflect.Value{}, false
@@ -14,9 +127,16 @@ func getLayerValue(layer any) (reflect.Value, bool) {
 	val := reflect.ValueOf(layer)
 	if val.IsZero() {
 		return val, false
+	} else if val.Kind() != reflect.Ptr {
+		return val, false
 	} else if val.IsNil() {
 		return val, false
-	} else if val.Kind() != reflect.Ptr {
+	}
+
+	// only ask layers that declare Enabled themselves, a promoted Enabled method
+	// would be called on the yet unset embedded field.
+	inner := unwrapStandIn(layer)
+	if enabler, ok := inner.(Enabler); ok && definesMethod(reflect.TypeOf(inner), "Enabled") && !enabler.Enabled() {
 		return val, false
 	}
 
@@ -33,6 +153,17 @@ func If[T interface{}](cond bool, layer T) T {
 	}
 }
 
+// IfOr is like If, but returns zero instead of the zero value of the layer's type if cond is false.
+// zero should be a sentinel that Layered skips, which lets the caller choose one for layer types
+// whose zero value is not obviously skippable.
+func IfOr[T interface{}](cond bool, layer T, zero T) T {
+	if cond {
+		return layer
+	} else {
+		return zero
+	}
+}
+
 // IfCallback returns the result of the layer function if cond is true, otherwise it returns a
 // zero value of the layer's type. This is useful for skipping entire layers based on a condition
 // when the layer is expensive to construct.
@@ -49,66 +180,406 @@ func IfCallback[T interface{}](cond bool, layer func() T) T {
 // being the outermost layer. This is useful for wrapping a base layer with additional functionality
 // without having to modify the base layer.
 func Layered[T interface{}](base T, layers ...T) (T, error) {
-	if len(layers) == 0 {
-		return base, nil
+	return LayeredWith[T](base, nil, layers...)
+}
+
+// LayeredWith is like Layered, but accepts a list of options that change how the cake is
+// constructed.
+func LayeredWith[T interface{}](base T, opts []Option, layers ...T) (T, error) {
+	var o = newOptions(opts)
+
+	var start time.Time
+	if o.profiler != nil || o.observer != nil {
+		start = time.Now()
+	}
+
+	res, valid, err := build(o, base, layers)
+	if err != nil {
+		return *new(T), o.nameError(err)
+	}
+
+	var d time.Duration
+	if o.profiler != nil || o.observer != nil {
+		d = time.Since(start)
+	}
+
+	if o.profiler != nil {
+		o.profiler(d, len(valid))
+	}
+
+	if o.logger != nil || o.observer != nil {
+		var names = make([]string, 0, len(valid))
+		for _, layer := range valid {
+			names = append(names, o.layerName(layer))
+		}
+
+		if o.logger != nil {
+			o.logger.Debug("layered cake", "layers", names, "base", o.layerName(base))
+		}
+
+		if o.observer != nil {
+			o.observer.ObserveBuild(BuildEvent{
+				Interface:  TypeOf[T]().String(),
+				LayerCount: len(valid),
+				Layers:     names,
+				Base:       o.layerName(base),
+				Duration:   d,
+			})
+		}
 	}
 
-	var entryLayer = -1
-	// get the name of T, which is the interface that all layers implement
-	var interfaceName = strings.Split(fmt.Sprintf("%T", new(T)), ".")[1]
+	return res, nil
+}
+
+// build wires the cake and returns its outermost layer along with the layers that were wired.
+func build[T interface{}](o *options, base T, layers []T) (T, []T, error) {
+	if err := checkInterface[T](); err != nil {
+		return *new(T), nil, err
+	}
+
+	// collect the valid layers first so they can be wired in a single pass.
+	var valid, indices = collect(o, layers)
+	notifySkippedLayers(layers, indices)
+	if o.skipMalformed {
+		valid, indices = dropMalformed(o, valid, indices)
+	}
+
+	if o.maxDepth >= 0 && len(valid) > o.maxDepth {
+		return *new(T), nil, fmt.Errorf("%w: %d layers, at most %d allowed", ErrMaxDepthExceeded, len(valid), o.maxDepth)
+	}
+
+	if o.copyLayers {
+		for i := range valid {
+			cp := copyLayer(o, valid[i])
+			o.copyName(valid[i], cp)
+			valid[i] = cp
+		}
+	}
+
+	if len(valid) == 0 {
+		return base, nil, nil
+	}
+
+	if err := validateConstraints(base, valid, indices); err != nil {
+		return *new(T), nil, err
+	}
+
+	if err := wire(o, base, valid, indices); err != nil {
+		return *new(T), nil, err
+	}
+
+	for depth, layer := range valid {
+		if aware, ok := unwrapStandIn(layer).(DepthAware); ok {
+			aware.SetDepth(depth)
+		}
+	}
+
+	return valid[0], valid, nil
+}
+
+// collect returns the layers that should be wired, skipping nil and disabled layers, along with
+// their positions in layers.
+func collect[T interface{}](o *options, layers []T) ([]T, []int) {
+	var valid []T
+	var indices []int
+	if o.capacityHint > 0 {
+		valid, indices = make([]T, 0, o.capacityHint), make([]int, 0, o.capacityHint)
+	}
 
-	// iterate through all provided layers.
 	for i := 0; i < len(layers); i++ {
 		// layers should be a pointer to a struct that implements T
-		curLayerValue, ok := getLayerValue(layers[i])
-		if !ok {
+		if _, ok := getLayerValue(layers[i]); ok && !o.skip(layers[i]) {
+			valid = append(valid, layers[i])
+			indices = append(indices, i)
+		}
+	}
+	return valid, indices
+}
+
+// notifySkippedLayers calls notifySkipped for each of layers whose position is not in indices,
+// which holds the sorted positions of the layers that are wired.
+func notifySkippedLayers[T interface{}](layers []T, indices []int) {
+	for i, j := 0, 0; i < len(layers); i++ {
+		if j < len(indices) && indices[j] == i {
+			j++
 			continue
 		}
+		notifySkipped(layers[i], reflect.ValueOf(layers[i]))
+	}
+}
+
+// dropMalformed removes the layers that cannot be wired from valid, reporting each of them to
+// the WithSkipMalformed func.
+func dropMalformed[T interface{}](o *options, valid []T, indices []int) ([]T, []int) {
+	if _, ok := nextSetters(valid); ok {
+		return valid, indices
+	}
 
-		if entryLayer == -1 {
-			entryLayer = i
+	var interfaceType = TypeOf[T]()
+	var keptLayers = make([]T, 0, len(valid))
+	var keptIndices = make([]int, 0, len(indices))
+	for i, layer := range valid {
+		if _, err := o.inspectLayer(layer, interfaceType); err != nil {
+			if o.onMalformed != nil {
+				o.onMalformed(&LayerError{Index: indices[i], Layer: layer, Err: err})
+			}
+			continue
 		}
+		keptLayers = append(keptLayers, layer)
+		keptIndices = append(keptIndices, indices[i])
+	}
+	return keptLayers, keptIndices
+}
+
+// checkInterface checks that T is an interface a cake can be built of.
+func checkInterface[T interface{}]() error {
+	if t := TypeOf[T](); t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		return fmt.Errorf("%w: %s", ErrEmptyInterface, t)
+	}
+	return nil
+}
+
+// checkBase checks that base can be wired under the valid layers, which it can't be if it is nil
+// and the innermost layer is not Terminal.
+func checkBase[T interface{}](base T, valid []T) error {
+	if !reflect.ValueOf(base).IsValid() && !isTerminal(valid[len(valid)-1]) {
+		return fmt.Errorf("%w: cake of type %s", ErrInvalidBase, TypeOf[T]())
+	}
+	return nil
+}
 
-		curLayerValue = curLayerValue.Elem()
+// wire sets the next layer of each of the valid layers, and the base as the next layer of the
+// last one. indices holds the position of each layer in the list passed to Layered.
+func wire[T interface{}](o *options, base T, valid []T, indices []int) error {
+	// get the type of T, which is the interface that all layers implement
+	var interfaceType = TypeOf[T]()
 
+	var hasBase = reflect.ValueOf(base).IsValid()
+	if err := checkBase(base, valid); err != nil {
+		return err
+	}
+
+	// when every layer can set its own next layer there is no need for reflection
+	if setters, ok := nextSetters(valid); ok {
+		for i := 0; i < len(setters)-1; i++ {
+			setters[i].SetNext(valid[i+1])
+		}
+		setters[len(setters)-1].SetNext(base)
+		return nil
+	}
+
+	for i := 0; i < len(valid); i++ {
 		// get a reference to the value of the embedded field that
 		// implements the interface that T represents
-		targetField := curLayerValue.FieldByName(interfaceName)
-		if !targetField.IsValid() || !targetField.CanSet() {
-			return *new(T), fmt.Errorf("field %s in layer '%T' cannot be set", targetField.String(), layers[i])
+		info, err := o.inspectLayer(valid[i], interfaceType)
+		if err != nil {
+			return &LayerError{Index: indices[i], Layer: valid[i], Err: err}
 		}
 
-		// if this is the last provided layer, set the embedded field to the base layer
-		if i == len(layers)-1 {
-			targetField.Set(reflect.ValueOf(base))
-			break
+		// set the embedded field to the next valid layer, or to the base layer if this is the last one
+		var next reflect.Value
+		if i == len(valid)-1 && !hasBase {
+			next = reflect.Zero(info.field.Type())
+		} else if i == len(valid)-1 {
+			next = reflect.ValueOf(base)
+		} else {
+			next = reflect.ValueOf(valid[i+1])
 		}
 
-		// now seek the next valid layer
-		var didSet bool
-		for j := i + 1; j < len(layers); j++ {
-			nextLayerValue, ok := getLayerValue(layers[j])
-			if !ok {
-				continue
-			}
+		if err := info.set(next); err != nil {
+			return &LayerError{Index: indices[i], Layer: valid[i], Err: err}
+		}
+	}
+
+	return nil
+}
+
+// LayeredAny is like Layered, but takes the base and layers as values of any type. This is useful
+// when they are assembled dynamically, e.g. from a registry. It returns an error describing the
+// missing or mismatched methods if the base or any non-nil layer does not implement T, instead of
+// deferring the failure to the first call that reaches it.
+func LayeredAny[T interface{}](base any, layers ...any) (T, error) {
+	typedBase, ok := base.(T)
+	if base == nil {
+		return *new(T), fmt.Errorf("%w: cake of type %s", ErrInvalidBase, TypeOf[T]())
+	} else if !ok {
+		return *new(T), fmt.Errorf("invalid base: %s", WhyNotAssignable[T](base))
+	}
+
+	var typedLayers = make([]T, len(layers))
+	for i, layer := range layers {
+		if layer == nil {
+			continue
+		}
+
+		typedLayer, ok := layer.(T)
+		if !ok {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: errors.New(WhyNotAssignable[T](layer))}
+		}
+		typedLayers[i] = typedLayer
+	}
+
+	return Layered[T](typedBase, typedLayers...)
+}
+
+// LayeredPlugins is like Layered, but requires every layer to also implement P, such as a plugin
+// interface that describes the layer. It returns the layers as P, from the outermost inward, along
+// with the cake. Nil and disabled layers are skipped and not returned. It returns a *LayerError
+// for the first layer that does not implement P, without wiring any layer.
+func LayeredPlugins[T interface{}, P interface{}](base T, layers ...T) (T, []P, error) {
+	var o = newOptions(nil)
+
+	var valid, indices = collect(o, layers)
+	var plugins = make([]P, 0, len(valid))
+	for i, layer := range valid {
+		plugin, ok := any(layer).(P)
+		if !ok {
+			return *new(T), nil, &LayerError{Index: indices[i], Layer: layer, Err: fmt.Errorf("does not implement %s", TypeOf[P]())}
+		}
+		plugins = append(plugins, plugin)
+	}
 
-			didSet = true
-			targetField.Set(nextLayerValue)
+	res, err := Layered[T](base, valid...)
+	if err != nil {
+		return *new(T), nil, err
+	}
+	return res, plugins, nil
+}
+
+// LayeredDedupBy is like Layered, but it first removes duplicate layers. keyFn is called for each
+// non-nil layer and only the first layer for any given key is kept; later layers with the same key
+// are skipped. This is useful for preventing a decorator from being applied twice when layers are
+// assembled from multiple sources.
+func LayeredDedupBy[T interface{}](base T, keyFn func(T) string, layers ...T) (T, error) {
+	var seen = make(map[string]struct{}, len(layers))
+	var deduped = make([]T, 0, len(layers))
+	for _, layer := range layers {
+		if _, ok := getLayerValue(layer); !ok {
+			continue
+		}
+
+		key := keyFn(layer)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		deduped = append(deduped, layer)
+	}
+
+	return Layered[T](base, deduped...)
+}
 
-			// i will be incremented just after this
-			// so set it to the previous layer
-			i = j - 1
+// Single wires a single layer over base. It is equivalent to Layered(base, layer), but unlike
+// Layered it returns an error if layer is nil instead of skipping it. This is useful for unit
+// testing a layer in isolation over a mock base.
+func Single[T interface{}](layer T, base T) (T, error) {
+	if _, ok := getLayerValue(layer); !ok {
+		return *new(T), fmt.Errorf("layer '%T' cannot be wired: must be a non-nil pointer", layer)
+	}
+
+	return Layered[T](base, layer)
+}
 
-			break
+// LayeredFactories constructs a cake from factories that each receive the next layer and return a
+// layer wrapping it. The factories are applied from the last to the first, so just like Layered
+// the first factory produces the outermost layer and the last one wraps the base. Nil factories
+// are skipped. No reflection is involved, which makes this suitable for layers that hold their
+// next layer in an unexported field.
+func LayeredFactories[T interface{}](base T, factories ...func(next T) T) (T, error) {
+	var res = base
+	for i := len(factories) - 1; i >= 0; i-- {
+		if factories[i] == nil {
+			continue
 		}
 
-		// if after iterating through all the layers we did not set the value of the embedded field,
-		// set it to the base layer's value and break the loop.
-		if !didSet {
-			targetField.Set(reflect.ValueOf(base))
-			break
+		next := factories[i](res)
+		if val := reflect.ValueOf(next); !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+			return *new(T), fmt.Errorf("factory %d returned a nil layer", i)
 		}
+		res = next
 	}
+	return res, nil
+}
 
-	return layers[entryLayer], nil
+// AsMiddleware returns layer as a middleware func in the common func(next T) T form, for use with
+// composition helpers outside of this package. The returned func sets the delegate of layer to next,
+// the same way Layered would, and returns layer. Since every call rewires the same layer, the func
+// should only be applied once. It panics if layer cannot be wired.
+func AsMiddleware[T interface{}](layer T) func(next T) T {
+	var o = newOptions(nil)
+	return func(next T) T {
+		if setter, ok := any(layer).(NextSetter[T]); ok {
+			setter.SetNext(next)
+			return layer
+		}
+
+		info, err := o.inspectLayer(layer, TypeOf[T]())
+		if err != nil {
+			panic(fmt.Sprintf("cake: AsMiddleware: layer '%T' cannot be wired: %v", layer, err))
+		} else if err := info.set(reflect.ValueOf(next)); err != nil {
+			panic(fmt.Sprintf("cake: AsMiddleware: layer '%T' cannot be wired: %v", layer, err))
+		}
+		return layer
+	}
+}
+
+// LayeredLazy is like Layered, but takes each layer as an entry that returns the layer and whether
+// it should be included. Each entry is called once, in order, and the layers of the entries that
+// return false are skipped. Entries created with Lazy only construct their layer when it is
+// included, which keeps the construction of expensive layers that are skipped from running at all.
+// Nil entries are skipped.
+func LayeredLazy[T interface{}](base T, layers ...func() (T, bool)) (T, error) {
+	var included = make([]T, 0, len(layers))
+	for _, entry := range layers {
+		if entry == nil {
+			continue
+		}
+
+		if layer, ok := entry(); ok {
+			included = append(included, layer)
+		}
+	}
+
+	return Layered[T](base, included...)
+}
+
+// Lazy returns an entry for LayeredLazy that calls layer to construct the layer only if cond is
+// true. It is the LayeredLazy counterpart of IfCallback.
+func Lazy[T interface{}](cond bool, layer func() T) func() (T, bool) {
+	return func() (T, bool) {
+		if !cond {
+			return *new(T), false
+		}
+		return layer(), true
+	}
+}
+
+// LayeredWithBaseFunc is like Layered, but constructs the base by calling baseFn. If baseFn
+// returns an error it is returned before any of the layers are touched. This keeps construction
+// of a fallible base and its layers in a single expression.
+func LayeredWithBaseFunc[T interface{}](baseFn func() (T, error), layers ...T) (T, error) {
+	base, err := baseFn()
+	if err != nil {
+		return *new(T), fmt.Errorf("failed to construct base: %w", err)
+	}
+
+	return Layered[T](base, layers...)
+}
+
+// LayeredChan is like Layered, but receives the layers from ch until it is closed. The layers are
+// wired in the order they arrive, so the first layer received becomes the outermost one. If ctx is
+// done before ch is closed, LayeredChan returns the error of ctx without wiring any layer.
+func LayeredChan[T interface{}](ctx context.Context, base T, ch <-chan T) (T, error) {
+	var layers []T
+	for {
+		select {
+		case <-ctx.Done():
+			return *new(T), fmt.Errorf("%d layers received before the channel was closed: %w", len(layers), ctx.Err())
+		case layer, ok := <-ch:
+			if !ok {
+				return Layered[T](base, layers...)
+			}
+			layers = append(layers, layer)
+		}
+	}
 }
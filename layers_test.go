@@ -1,6 +1,13 @@
 package cake
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 type Service interface {
 	Fruits() []string
@@ -45,6 +52,12 @@ func (l *LayerD) Veggies() []string {
 
 type LayerE struct{ Service } // E for Empty!
 
+// brokenLayer has no embedded Service, so it cannot be wired.
+type brokenLayer struct{ service Service }
+
+func (l *brokenLayer) Fruits() []string  { return l.service.Fruits() }
+func (l *brokenLayer) Veggies() []string { return l.service.Veggies() }
+
 func Test_Layers(t *testing.T) {
 	testTable := map[string]struct {
 		baseLayer       Service
@@ -157,3 +170,685 @@ func Test_Layers(t *testing.T) {
 		})
 	}
 }
+
+func Test_LayeredDedupBy(t *testing.T) {
+	var keyFn = func(s Service) string { return fmt.Sprintf("%T", s) }
+
+	svc, err := LayeredDedupBy[Service](&LayerA{}, keyFn,
+		&LayerB{},
+		&LayerD{},
+		&LayerB{},
+		If(false, &LayerC{}),
+		&LayerD{},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	expectedVeggies := []string{"Artichoke", "Dill", "Basil"}
+	if fmt.Sprint(svc.Veggies()) != fmt.Sprint(expectedVeggies) {
+		t.Fatalf("expectedVeggies %v, got %v", expectedVeggies, svc.Veggies())
+	}
+}
+
+func Test_TypeOf(t *testing.T) {
+	typ := TypeOf[Service]()
+	if typ.Kind() != reflect.Interface {
+		t.Fatalf("expected kind %s, got %s", reflect.Interface, typ.Kind())
+	}
+
+	if typ.Name() != "Service" {
+		t.Fatalf("expected name Service, got %s", typ.Name())
+	}
+
+	if !reflect.TypeOf(&LayerB{}).Implements(typ) {
+		t.Fatalf("expected %s to implement %s", reflect.TypeOf(&LayerB{}), typ)
+	}
+}
+
+type mockService struct {
+	fruits  []string
+	veggies []string
+}
+
+func (m *mockService) Fruits() []string  { return m.fruits }
+func (m *mockService) Veggies() []string { return m.veggies }
+
+func Test_Single(t *testing.T) {
+	svc, err := Single[Service](&LayerD{}, &mockService{fruits: []string{"Mock"}})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Mock", "Durian"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	if _, err := Single[Service](If(false, &LayerD{}), &mockService{}); err == nil {
+		t.Fatalf("expected an error for a nil layer")
+	}
+}
+
+func Test_Layered_OnlyNilLayers(t *testing.T) {
+	base := &LayerA{}
+	svc, err := Layered[Service](base, If(false, &LayerB{}), If(false, &LayerC{}))
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if svc != base {
+		t.Fatalf("expected the base to be returned, got %T", svc)
+	}
+}
+
+func Benchmark_Layered_NilLayers(b *testing.B) {
+	var layers = make([]Service, 500)
+	for i := 0; i < len(layers); i += 50 {
+		layers[i] = &LayerB{}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Layered[Service](&LayerA{}, layers...); err != nil {
+			b.Fatalf("failed to layer cake: %+v", err)
+		}
+	}
+}
+
+func Benchmark_Layered_CapacityHint(b *testing.B) {
+	var layers = make([]Service, 500)
+	for i := range layers {
+		layers[i] = &LayerB{}
+	}
+
+	b.Run("Unhinted", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Layered[Service](&LayerA{}, layers...); err != nil {
+				b.Fatalf("failed to layer cake: %+v", err)
+			}
+		}
+	})
+
+	b.Run("Hinted", func(b *testing.B) {
+		var opts = []Option{WithCapacityHint(len(layers))}
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := LayeredWith[Service](&LayerA{}, opts, layers...); err != nil {
+				b.Fatalf("failed to layer cake: %+v", err)
+			}
+		}
+	})
+}
+
+type toggledLayer struct {
+	Service
+	enabled bool
+}
+
+func (l *toggledLayer) Enabled() bool { return l.enabled }
+
+func (l *toggledLayer) Fruits() []string {
+	return append(l.Service.Fruits(), "Toggled")
+}
+
+func Test_Layered_Enabler(t *testing.T) {
+	disabled := &toggledLayer{enabled: false}
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, disabled, &toggledLayer{enabled: true}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Toggled", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	if disabled.Service != nil {
+		t.Fatalf("expected the disabled layer not to be wired")
+	}
+
+	for _, layer := range Layers(svc) {
+		if layer == Service(disabled) {
+			t.Fatalf("expected the disabled layer not to be part of the chain")
+		}
+	}
+}
+
+// setterLayer has no embedded Service and can only be wired through SetNext.
+type setterLayer struct {
+	next  Service
+	fruit string
+}
+
+func (l *setterLayer) SetNext(next Service) { l.next = next }
+
+func (l *setterLayer) Fruits() []string {
+	return append(l.next.Fruits(), l.fruit)
+}
+
+func (l *setterLayer) Veggies() []string {
+	return l.next.Veggies()
+}
+
+func Test_Layered_NextSetter(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{},
+		&setterLayer{fruit: "Cherry"},
+		If(false, &setterLayer{fruit: "Date"}),
+		&setterLayer{fruit: "Elderberry"},
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Elderberry", "Cherry"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	// mixing setter layers with embedding layers falls back to reflection,
+	// which cannot wire a layer without an embedded Service.
+	if _, err := Layered[Service](&LayerA{}, &setterLayer{}, &LayerB{}); err == nil {
+		t.Fatalf("expected an error when mixing setter and embedding layers")
+	}
+}
+
+func Benchmark_Layered_NextSetter(b *testing.B) {
+	b.Run("SetNext", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Layered[Service](&LayerA{}, &setterLayer{}, &setterLayer{}, &setterLayer{}); err != nil {
+				b.Fatalf("failed to layer cake: %+v", err)
+			}
+		}
+	})
+
+	b.Run("Reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{}); err != nil {
+				b.Fatalf("failed to layer cake: %+v", err)
+			}
+		}
+	})
+}
+
+// Benchmark_BuildVsCall compares the cost of building a cake with Layered against the cost of
+// calling a method through the built cake. Building uses reflection to set the embedded field of
+// every layer, while a call through the cake is nothing more than a chain of ordinary interface
+// method calls: there is no reflection at call time, and the allocations of Call come from the
+// appends in the layers' method bodies. Building the four layers below costs about as much as
+// twenty calls. The cost of cake is paid at build time, so build a cake once and reuse it rather
+// than building it per request.
+func Benchmark_BuildVsCall(b *testing.B) {
+	b.Run("Build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{}); err != nil {
+				b.Fatalf("failed to layer cake: %+v", err)
+			}
+		}
+	})
+
+	b.Run("Call", func(b *testing.B) {
+		svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+		if err != nil {
+			b.Fatalf("failed to layer cake: %+v", err)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			svc.Veggies()
+		}
+	})
+}
+
+func Test_LayeredFactories(t *testing.T) {
+	var wrap = func(fruit string) func(next Service) Service {
+		return func(next Service) Service { return &setterLayer{next: next, fruit: fruit} }
+	}
+
+	svc, err := LayeredFactories[Service](&LayerA{}, wrap("Banana"), nil, wrap("Durian"))
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected, err := Layered[Service](&LayerA{}, &setterLayer{fruit: "Banana"}, &setterLayer{fruit: "Durian"})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expected.Fruits()) {
+		t.Fatalf("expectedFruits %v, got %v", expected.Fruits(), svc.Fruits())
+	}
+
+	_, err = LayeredFactories[Service](&LayerA{}, func(Service) Service { return nil })
+	if err == nil {
+		t.Fatalf("expected an error for a factory returning nil")
+	}
+}
+
+func Test_AsMiddleware(t *testing.T) {
+	middleware := []func(next Service) Service{
+		AsMiddleware[Service](&LayerB{}),
+		AsMiddleware[Service](&LayerC{}),
+		AsMiddleware[Service](&LayerD{}),
+	}
+
+	var svc Service = &LayerA{}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		svc = middleware[i](svc)
+	}
+
+	expected, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expected.Fruits()) {
+		t.Fatalf("expectedFruits %v, got %v", expected.Fruits(), svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != fmt.Sprint(expected.Veggies()) {
+		t.Fatalf("expectedVeggies %v, got %v", expected.Veggies(), svc.Veggies())
+	}
+
+	testTable := map[string]struct {
+		layer       Service
+		expectedErr string
+	}{
+		"Missing field": {
+			layer:       &brokenLayer{},
+			expectedErr: "field Service not found",
+		},
+		"Field of the wrong type": {
+			layer:       &stringFieldLayer{},
+			expectedErr: ErrFieldTypeMismatch.Error(),
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, "cannot be wired") || !strings.Contains(msg, testCase.expectedErr) {
+					t.Fatalf("expected a descriptive panic, got %q", msg)
+				}
+			}()
+			AsMiddleware[Service](testCase.layer)(&LayerA{})
+		})
+	}
+}
+
+func Test_Layered_LeadingNilLayers(t *testing.T) {
+	testTable := map[string]struct {
+		layers          []Service
+		expectedEntry   string
+		expectedFruits  []string
+		expectedVeggies []string
+	}{
+		"[nil, valid, valid]": {
+			layers:          []Service{If(false, &LayerC{}), &LayerB{}, &LayerD{}},
+			expectedEntry:   "*cake.LayerB",
+			expectedFruits:  []string{"Apple", "Durian", "Banana"},
+			expectedVeggies: []string{"Artichoke", "Dill", "Basil"},
+		},
+		"[nil, nil, valid]": {
+			layers:          []Service{If(false, &LayerB{}), If(false, &LayerC{}), &LayerD{}},
+			expectedEntry:   "*cake.LayerD",
+			expectedFruits:  []string{"Apple", "Durian"},
+			expectedVeggies: []string{"Artichoke", "Dill"},
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			svc, err := Layered[Service](&LayerA{}, testCase.layers...)
+			if err != nil {
+				t.Fatalf("failed to layer cake: %+v", err)
+			}
+
+			if entry := fmt.Sprintf("%T", svc); entry != testCase.expectedEntry {
+				t.Fatalf("expected entry layer %s, got %s", testCase.expectedEntry, entry)
+			}
+
+			if fmt.Sprint(svc.Fruits()) != fmt.Sprint(testCase.expectedFruits) {
+				t.Fatalf("expectedFruits %v, got %v", testCase.expectedFruits, svc.Fruits())
+			}
+
+			if fmt.Sprint(svc.Veggies()) != fmt.Sprint(testCase.expectedVeggies) {
+				t.Fatalf("expectedVeggies %v, got %v", testCase.expectedVeggies, svc.Veggies())
+			}
+		})
+	}
+}
+
+type depthLayer struct {
+	Service
+	depth int
+}
+
+func (l *depthLayer) SetDepth(depth int) { l.depth = depth }
+
+func Test_Layered_DepthAware(t *testing.T) {
+	outer, inner := &depthLayer{depth: -1}, &depthLayer{depth: -1}
+	_, err := Layered[Service](&LayerA{}, If(false, &LayerB{}), outer, &LayerC{}, inner)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if outer.depth != 0 {
+		t.Fatalf("expected the outer layer at depth 0, got %d", outer.depth)
+	}
+
+	if inner.depth != 2 {
+		t.Fatalf("expected the inner layer at depth 2, got %d", inner.depth)
+	}
+}
+
+func Test_LayeredWithBaseFunc(t *testing.T) {
+	svc, err := LayeredWithBaseFunc[Service](func() (Service, error) { return &LayerA{}, nil }, &LayerB{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	errBase := errors.New("no database")
+	layer := &LayerB{}
+	_, err = LayeredWithBaseFunc[Service](func() (Service, error) { return nil, errBase }, layer)
+	if !errors.Is(err, errBase) {
+		t.Fatalf("expected %v, got %v", errBase, err)
+	}
+
+	if layer.Service != nil {
+		t.Fatalf("expected the layer not to be wired")
+	}
+}
+
+func Test_Layered_TypedNilLayer(t *testing.T) {
+	var lb *LayerB
+	var layer Service = lb
+	if layer == nil {
+		t.Fatalf("expected the interface holding a nil pointer to be non-nil")
+	}
+
+	svc, err := Layered[Service](&LayerA{}, lb, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if _, ok := svc.(*LayerD); !ok {
+		t.Fatalf("expected the typed nil layer to be skipped, got entry layer %T", svc)
+	}
+
+	expectedFruits := []string{"Apple", "Durian"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+}
+
+type FruitService interface {
+	Fruits() []string
+}
+
+type VeggieService interface {
+	Veggies() []string
+}
+
+// ComposedService is composed of two smaller interfaces instead of declaring its methods directly.
+type ComposedService interface {
+	FruitService
+	VeggieService
+}
+
+type composedBase struct{}
+
+func (composedBase) Fruits() []string  { return []string{"Apple"} }
+func (composedBase) Veggies() []string { return []string{"Artichoke"} }
+
+// composedFruitLayer overrides a method of FruitService.
+type composedFruitLayer struct{ ComposedService }
+
+func (l *composedFruitLayer) Fruits() []string {
+	return append(l.ComposedService.Fruits(), "Fig")
+}
+
+// composedVeggieLayer overrides a method of VeggieService.
+type composedVeggieLayer struct{ ComposedService }
+
+func (l *composedVeggieLayer) Veggies() []string {
+	return append(l.ComposedService.Veggies(), "Kale")
+}
+
+func Test_Layered_ComposedInterface(t *testing.T) {
+	svc, err := Layered[ComposedService](composedBase{}, &composedFruitLayer{}, &composedVeggieLayer{}, &composedFruitLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Fig", "Fig"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	expectedVeggies := []string{"Artichoke", "Kale"}
+	if fmt.Sprint(svc.Veggies()) != fmt.Sprint(expectedVeggies) {
+		t.Fatalf("expectedVeggies %v, got %v", expectedVeggies, svc.Veggies())
+	}
+
+	if n := len(Layers(svc)); n != 3 {
+		t.Fatalf("expected 3 layers, got %d", n)
+	}
+}
+
+// fruitsOnlyBase is missing the Veggies method of Service.
+type fruitsOnlyBase struct{}
+
+func (fruitsOnlyBase) Fruits() []string { return []string{"Apple"} }
+
+func Test_LayeredAny(t *testing.T) {
+	svc, err := LayeredAny[Service](&LayerA{}, &LayerB{}, nil, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	testTable := map[string]struct {
+		base        any
+		layers      []any
+		expectedErr string
+	}{
+		"Base missing a method": {
+			base:        fruitsOnlyBase{},
+			expectedErr: "invalid base: cake.fruitsOnlyBase does not implement cake.Service: missing method Veggies",
+		},
+		"Nil base": {
+			base:        nil,
+			expectedErr: "base must not be a nil interface value: cake of type cake.Service",
+		},
+		"Layer missing a method": {
+			base:        &LayerA{},
+			layers:      []any{&LayerB{}, fruitsOnlyBase{}},
+			expectedErr: "layer 'cake.fruitsOnlyBase': cake.fruitsOnlyBase does not implement cake.Service: missing method Veggies",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := LayeredAny[Service](testCase.base, testCase.layers...)
+			if err == nil || err.Error() != testCase.expectedErr {
+				t.Fatalf("expected error %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_Layered_NilBase(t *testing.T) {
+	var base Service
+	_, err := Layered[Service](base, &LayerB{})
+	if !errors.Is(err, ErrInvalidBase) {
+		t.Fatalf("expected %v, got %v", ErrInvalidBase, err)
+	}
+
+	_, err = Layered[Service](base, &setterLayer{})
+	if !errors.Is(err, ErrInvalidBase) {
+		t.Fatalf("expected %v for layers implementing NextSetter, got %v", ErrInvalidBase, err)
+	}
+}
+
+func Test_IfOr(t *testing.T) {
+	testTable := map[string]struct {
+		cond           bool
+		zero           Service
+		expectedFruits []string
+	}{
+		"Returns the layer when cond is true": {
+			cond:           true,
+			zero:           valueLayer{},
+			expectedFruits: []string{"Apple", "Durian", "Banana"},
+		},
+		"Zero value layer is skipped": {
+			cond:           false,
+			zero:           valueLayer{},
+			expectedFruits: []string{"Apple", "Banana"},
+		},
+		"Non-zero value layer is skipped": {
+			cond:           false,
+			zero:           valueLayer{Service: &LayerA{}},
+			expectedFruits: []string{"Apple", "Banana"},
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			svc, err := Layered[Service](&LayerA{}, &LayerB{}, IfOr[Service](testCase.cond, &LayerD{}, testCase.zero))
+			if err != nil {
+				t.Fatalf("failed to layer cake: %+v", err)
+			}
+
+			if fmt.Sprint(svc.Fruits()) != fmt.Sprint(testCase.expectedFruits) {
+				t.Fatalf("expectedFruits %v, got %v", testCase.expectedFruits, svc.Fruits())
+			}
+		})
+	}
+}
+
+func Test_Layered_EmptyInterface(t *testing.T) {
+	_, err := Layered[any](&LayerA{}, &LayerB{})
+	if !errors.Is(err, ErrEmptyInterface) {
+		t.Fatalf("expected %v, got %v", ErrEmptyInterface, err)
+	}
+
+	if err.Error() != "cannot layer an interface without methods: interface {}" {
+		t.Fatalf("expected a descriptive error, got %q", err)
+	}
+}
+
+func Test_LayeredChan(t *testing.T) {
+	ch := make(chan Service)
+	go func() {
+		defer close(ch)
+		ch <- &LayerB{}
+		ch <- &LayerC{}
+		ch <- &LayerD{}
+	}()
+
+	svc, err := LayeredChan[Service](context.Background(), &LayerA{}, ch)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if Describe(svc) != "LayerB -> LayerC -> LayerD -> LayerA" {
+		t.Fatalf("expected the layers in arrival order, got %s", Describe(svc))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = LayeredChan[Service](ctx, &LayerA{}, make(chan Service))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+// Plugin describes a layer loaded as a plugin.
+type Plugin interface {
+	Name() string
+	Version() string
+}
+
+type pluginLayer struct {
+	Service
+	name string
+}
+
+func (l *pluginLayer) Name() string    { return l.name }
+func (l *pluginLayer) Version() string { return "v1" }
+
+func Test_LayeredPlugins(t *testing.T) {
+	svc, plugins, err := LayeredPlugins[Service, Plugin](&LayerA{}, &pluginLayer{name: "auth"}, nil, &pluginLayer{name: "cache"})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if Describe(svc) != "pluginLayer -> pluginLayer -> LayerA" {
+		t.Fatalf("expected both plugins to be wired, got %s", Describe(svc))
+	}
+
+	var names []string
+	for _, plugin := range plugins {
+		names = append(names, plugin.Name()+"@"+plugin.Version())
+	}
+	if fmt.Sprint(names) != "[auth@v1 cache@v1]" {
+		t.Fatalf("expected [auth@v1 cache@v1], got %v", names)
+	}
+
+	_, _, err = LayeredPlugins[Service, Plugin](&LayerA{}, &pluginLayer{name: "auth"}, nil, &LayerB{})
+	var layerErr *LayerError
+	if !errors.As(err, &layerErr) || layerErr.Index != 2 {
+		t.Fatalf("expected a LayerError for layer 2, got %v", err)
+	}
+
+	if err.Error() != "layer '*cake.LayerB': does not implement cake.Plugin" {
+		t.Fatalf("expected a descriptive error, got %q", err)
+	}
+}
+
+func Test_LayeredLazy(t *testing.T) {
+	var constructed []string
+	var newLayer = func(name string, layer Service) func() Service {
+		return func() Service {
+			constructed = append(constructed, name)
+			return layer
+		}
+	}
+
+	svc, err := LayeredLazy[Service](&LayerA{},
+		Lazy(true, newLayer("B", &LayerB{})),
+		Lazy(false, newLayer("C", &LayerC{})),
+		nil,
+		func() (Service, bool) { return &LayerD{}, true },
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(constructed) != "[B]" {
+		t.Fatalf("expected only the included layer to be constructed, got %v", constructed)
+	}
+
+	if Describe(svc) != "LayerB -> LayerD -> LayerA" {
+		t.Fatalf("expected LayerB -> LayerD -> LayerA, got %s", Describe(svc))
+	}
+}
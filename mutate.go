@@ -0,0 +1,117 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RemoveMatching removes every layer of chain for which match returns true and wires the
+// remaining layers around the base again, keeping their order. The base is never removed. The
+// layers are modified in place using the default field resolution, and the new outermost layer is
+// returned.
+func RemoveMatching[T interface{}](chain T, match func(layer T) bool) (T, error) {
+	layers, base, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	var survivors = make([]T, 0, len(layers))
+	for _, layer := range layers {
+		if !match(layer) {
+			survivors = append(survivors, layer)
+		}
+	}
+
+	return Layered[T](base, survivors...)
+}
+
+// Swap exchanges the layers at depths i and j of chain, where depth 0 is the outermost layer, and
+// wires the layers around the base again. All other layers keep their position. The layers are
+// modified in place, and the new outermost layer is returned.
+func Swap[T interface{}](chain T, i, j int) (T, error) {
+	layers, base, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	} else if i < 0 || i >= len(layers) || j < 0 || j >= len(layers) {
+		return *new(T), fmt.Errorf("cannot swap layers %d and %d of a chain with %d layers", i, j, len(layers))
+	}
+
+	layers[i], layers[j] = layers[j], layers[i]
+	return Layered[T](base, layers...)
+}
+
+// Rebuild wires layers around base again. It is the inverse of Layers and Base, so that
+// Rebuild(Base(chain), Layers(chain)) produces a chain equal to chain.
+func Rebuild[T interface{}](base T, layers []T) (T, error) {
+	return Layered[T](base, layers...)
+}
+
+// RewrapResult wires copies of the layers of chain over result. This is meant for methods of T that
+// return a new T, such as WithOption(o Opt) T. When a layer does not override such a method, or
+// overrides it by returning the result of its next layer, the caller only gets back the value the
+// base returned, without any of the layers above it. RewrapResult restores them:
+//
+//	func (l *loggingLayer) WithOption(o Opt) Service {
+//	    res, _ := cake.RewrapResult[Service](l, l.Service.WithOption(o))
+//	    return res
+//	}
+//
+// The layers are shallow copies, so the layers of chain remain wired as they were.
+func RewrapResult[T interface{}](chain T, result T) (T, error) {
+	var o = newOptions(nil)
+
+	layers, _, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for i := range layers {
+		layers[i] = copyLayer(o, layers[i])
+	}
+	return Layered[T](result, layers...)
+}
+
+// RebuildAbove keeps the first layer of chain of type *L, along with every layer inward of it and
+// the base, and wires newOuter over it as fresh outer layers. The layers outward of it are
+// discarded. This is useful for reconfiguring the outer decorators of a cake without constructing
+// its stable inner part again. It returns an error if chain has no layer of type *L.
+func RebuildAbove[T interface{}, L interface{}](chain T, newOuter ...T) (T, error) {
+	found, ok := Find[*L](chain)
+	if !ok {
+		return *new(T), fmt.Errorf("no layer of type %T", found)
+	}
+
+	// found was reached by walking chain, so it is a T
+	return Layered[T](any(found).(T), newOuter...)
+}
+
+// ReplaceBase sets the next layer of the innermost layer of chain to newBase, keeping every layer
+// as it is, and returns chain. This is useful for pointing a cake at a new resource, such as a new
+// database handle. If chain has no layers it is a bare base, and newBase is returned instead. It
+// returns ErrNotWalkable if chain has a layer wired through SetNext, since its base can't be reached.
+func ReplaceBase[T interface{}](chain T, newBase T) (T, error) {
+	if !reflect.ValueOf(newBase).IsValid() {
+		return *new(T), fmt.Errorf("%w: cake of type %s", ErrInvalidBase, TypeOf[T]())
+	}
+
+	layers, _, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	} else if len(layers) == 0 {
+		return newBase, nil
+	}
+
+	innermost := layers[len(layers)-1]
+	if setter, ok := any(innermost).(NextSetter[T]); ok {
+		setter.SetNext(newBase)
+		return chain, nil
+	}
+
+	info, err := newOptions(nil).inspectLayer(innermost, TypeOf[T]())
+	if err != nil {
+		return *new(T), &LayerError{Index: len(layers) - 1, Layer: innermost, Err: err}
+	} else if err := info.set(reflect.ValueOf(newBase)); err != nil {
+		return *new(T), &LayerError{Index: len(layers) - 1, Layer: innermost, Err: err}
+	}
+	return chain, nil
+}
@@ -0,0 +1,196 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// debugLayer is a marker for layers that should be removed from production chains.
+type debugLayer interface {
+	debug()
+}
+
+type debugFruitLayer struct{ Service }
+
+func (l *debugFruitLayer) debug() {}
+
+func (l *debugFruitLayer) Fruits() []string {
+	return append(l.Service.Fruits(), "Debug")
+}
+
+func Test_RemoveMatching(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &debugFruitLayer{}, &LayerD{}, &debugFruitLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err = RemoveMatching(svc, func(layer Service) bool {
+		_, ok := layer.(debugLayer)
+		return ok
+	})
+	if err != nil {
+		t.Fatalf("failed to remove layers: %+v", err)
+	}
+
+	expected := "LayerB -> LayerD -> LayerA"
+	if desc := Describe(svc); desc != expected {
+		t.Fatalf("expected %q, got %q", expected, desc)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+}
+
+func Test_Swap(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err = Swap(svc, 1, 2)
+	if err != nil {
+		t.Fatalf("failed to swap layers: %+v", err)
+	}
+
+	expectedVeggies := []string{"Artichoke", "Cilantro", "Dill", "Basil"}
+	if fmt.Sprint(svc.Veggies()) != fmt.Sprint(expectedVeggies) {
+		t.Fatalf("expectedVeggies %v, got %v", expectedVeggies, svc.Veggies())
+	}
+
+	if _, err := Swap(svc, 0, 3); err == nil {
+		t.Fatalf("expected an error for an out of range depth")
+	}
+}
+
+func Test_Rebuild(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+	expectedVeggies := fmt.Sprint(svc.Veggies())
+
+	rebuilt, err := Rebuild(Base(svc), Layers(svc))
+	if err != nil {
+		t.Fatalf("failed to rebuild cake: %+v", err)
+	}
+
+	if !ChainEqual(svc, rebuilt) {
+		t.Fatalf("expected %q, got %q", Describe(svc), Describe(rebuilt))
+	}
+
+	if fmt.Sprint(rebuilt.Veggies()) != expectedVeggies {
+		t.Fatalf("expectedVeggies %s, got %v", expectedVeggies, rebuilt.Veggies())
+	}
+}
+
+// Greeter has a fluent method that returns a new Greeter.
+type Greeter interface {
+	Greet() string
+	WithName(name string) Greeter
+}
+
+type greeterBase struct{ name string }
+
+func (g *greeterBase) Greet() string                { return "Hello, " + g.name }
+func (g *greeterBase) WithName(name string) Greeter { return &greeterBase{name: name} }
+
+type exclaimLayer struct{ Greeter }
+
+func (l *exclaimLayer) Greet() string { return l.Greeter.Greet() + "!" }
+
+func Test_RewrapResult(t *testing.T) {
+	chain, err := Layered[Greeter](&greeterBase{name: "world"}, &exclaimLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	// the promoted WithName returns the value of the base, without the layer
+	if greeting := chain.WithName("cake").Greet(); greeting != "Hello, cake" {
+		t.Fatalf("expected the fluent result to skip the layers, got %q", greeting)
+	}
+
+	res, err := RewrapResult(chain, chain.WithName("cake"))
+	if err != nil {
+		t.Fatalf("failed to rewrap result: %+v", err)
+	}
+
+	if greeting := res.Greet(); greeting != "Hello, cake!" {
+		t.Fatalf("expected the layers to be applied to the result, got %q", greeting)
+	}
+
+	if greeting := chain.Greet(); greeting != "Hello, world!" {
+		t.Fatalf("expected the original chain to be left as it was, got %q", greeting)
+	}
+}
+
+func Test_RebuildAbove(t *testing.T) {
+	base, inner := &LayerA{}, &LayerC{}
+	chain, err := Layered[Service](base, &LayerB{}, inner)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	res, err := RebuildAbove[Service, LayerC](chain, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to rebuild: %+v", err)
+	}
+
+	if Describe(res) != "LayerD -> LayerC -> LayerA" {
+		t.Fatalf("expected LayerD -> LayerC -> LayerA, got %s", Describe(res))
+	}
+
+	if Layers(res)[1] != Service(inner) || Base(res) != Service(base) {
+		t.Fatalf("expected the inner layers to be kept as they were")
+	}
+
+	if _, err := RebuildAbove[Service, LayerE](chain, &LayerD{}); err == nil {
+		t.Fatalf("expected an error for a missing layer")
+	}
+}
+
+func Test_ReplaceBase(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	res, err := ReplaceBase[Service](chain, &mockService{fruits: []string{"Mango"}, veggies: []string{"Mint"}})
+	if err != nil {
+		t.Fatalf("failed to replace base: %+v", err)
+	}
+
+	if res != chain {
+		t.Fatalf("expected the same outer chain to be returned")
+	}
+
+	if fmt.Sprint(res.Fruits()) != "[Mango Durian Banana]" {
+		t.Fatalf("expected [Mango Durian Banana], got %v", res.Fruits())
+	}
+
+	if fmt.Sprint(res.Veggies()) != "[Mint Dill Basil]" {
+		t.Fatalf("expected [Mint Dill Basil], got %v", res.Veggies())
+	}
+
+	newBase := &LayerA{}
+	if res, err := ReplaceBase[Service](&mockService{}, newBase); err != nil || res != Service(newBase) {
+		t.Fatalf("expected a bare base to be replaced, got %v (%v)", res, err)
+	}
+}
+
+func Test_ReplaceBase_NextSetter(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &setterLayer{fruit: "X"}, &setterLayer{fruit: "Y"})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if _, err := ReplaceBase[Service](chain, &LayerD{Service: &LayerA{}}); !errors.Is(err, ErrNotWalkable) {
+		t.Fatalf("expected %v, got %v", ErrNotWalkable, err)
+	}
+
+	if fmt.Sprint(chain.Fruits()) != "[Apple Y X]" {
+		t.Fatalf("expected the chain to be left as it was, got %v", chain.Fruits())
+	}
+}
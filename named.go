@@ -0,0 +1,87 @@
+package cake
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Named is a layer with a name that identifies it in errors, build hooks and Describe in place of
+// the name of its type. This is useful when many layers share a type, such as instances of a
+// generic layer. A Named is created with Name.
+type Named[T interface{}] struct {
+	Name  string
+	Layer T
+}
+
+// Name returns layer with the given name.
+func Name[T interface{}](name string, layer T) Named[T] {
+	return Named[T]{Name: name, Layer: layer}
+}
+
+// LayeredNamed is like Layered, but takes named layers and returns the cake as a Chain that
+// remembers their names. See LayeredNamedWith.
+func LayeredNamed[T interface{}](base T, layers ...Named[T]) (*Chain[T], error) {
+	return LayeredNamedWith[T](base, nil, layers...)
+}
+
+// LayeredNamedWith is like LayeredWith, but takes named layers. The names are used in place of the
+// type of each layer in any *LayerError returned by the build and in the names reported to the build
+// hooks. They are only known to this build and to the returned Chain, whose Describe reports them
+// too. Layers with an empty name are identified by their type as usual.
+func LayeredNamedWith[T interface{}](base T, opts []Option, layers ...Named[T]) (*Chain[T], error) {
+	var names = make(map[any]string, len(layers))
+	var values = make([]T, len(layers))
+	for i, layer := range layers {
+		if isNameable(layer.Layer) && layer.Name != "" {
+			names[any(layer.Layer)] = layer.Name
+		}
+		values[i] = layer.Layer
+	}
+
+	opts = append(append([]Option(nil), opts...), func(o *options) { o.names = names })
+	res, err := LayeredWith[T](base, opts, values...)
+	if err != nil {
+		return nil, err
+	}
+	return &Chain[T]{value: res, meta: make(map[string]any), names: names}, nil
+}
+
+// isNameable reports whether layer can be given a name. Only pointers are named, which also
+// keeps values that aren't comparable out of the maps of names.
+func isNameable(layer any) bool {
+	t := reflect.TypeOf(layer)
+	return t != nil && t.Kind() == reflect.Ptr
+}
+
+// lookupName returns the name of layer in names, or else the name of its type as in Describe.
+func lookupName(names map[any]string, layer any) string {
+	if isNameable(layer) {
+		if name, ok := names[layer]; ok {
+			return name
+		}
+	}
+	return layerName(layer)
+}
+
+// layerName returns the name of layer in this build, or else the name of its type.
+func (o *options) layerName(layer any) string {
+	return lookupName(o.names, layer)
+}
+
+// copyName gives the copy cp of layer the name of layer.
+func (o *options) copyName(layer any, cp any) {
+	if !isNameable(layer) {
+		return
+	} else if name, ok := o.names[layer]; ok {
+		o.names[cp] = name
+	}
+}
+
+// nameError sets the name of the layer of err, if err is a *LayerError for a named layer.
+func (o *options) nameError(err error) error {
+	var layerErr *LayerError
+	if errors.As(err, &layerErr) && layerErr.Name == "" && isNameable(layerErr.Layer) {
+		layerErr.Name = o.names[layerErr.Layer]
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+// instrumentLayer is a generic layer, so its type name alone doesn't identify an instance.
+type instrumentLayer[X any] struct {
+	Service
+	calls int
+}
+
+func (l *instrumentLayer[X]) Fruits() []string {
+	l.calls++
+	return l.Service.Fruits()
+}
+
+func Test_LayeredNamed(t *testing.T) {
+	outer, inner := &instrumentLayer[int]{}, &instrumentLayer[int]{}
+	recorder := &eventRecorder{}
+	chain, err := LayeredNamedWith[Service](&LayerA{}, []Option{WithObserver(recorder)},
+		Name[Service]("outer-metrics", outer),
+		Name[Service]("", &LayerB{}),
+		Name[Service]("nil", nil),
+		Name[Service]("inner-metrics", inner),
+	)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc := chain.Value()
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" || outer.calls != 1 || inner.calls != 1 {
+		t.Fatalf("expected both instruments to be called once, got %d and %d", outer.calls, inner.calls)
+	}
+
+	expected := "outer-metrics -> LayerB -> inner-metrics -> LayerA"
+	if chain.Describe() != expected || chain.Snapshot().String() != expected {
+		t.Fatalf("expected %s, got %s", expected, chain.Describe())
+	}
+
+	if fmt.Sprint(recorder.events[0].Layers) != "[outer-metrics LayerB inner-metrics]" {
+		t.Fatalf("expected the observer to see the names, got %v", recorder.events[0].Layers)
+	}
+
+	// the names are not known outside of the named build
+	if Describe(svc) != "instrumentLayer[int] -> LayerB -> instrumentLayer[int] -> LayerA" {
+		t.Fatalf("expected Describe to report the types, got %s", Describe(svc))
+	}
+
+	if _, err := LayeredWith[Service](&LayerA{}, []Option{WithObserver(recorder)}, inner, &LayerC{}); err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(recorder.events[1].Layers) != "[instrumentLayer[int] LayerC]" {
+		t.Fatalf("expected the name not to leak into another build, got %v", recorder.events[1].Layers)
+	}
+
+	_, err = LayeredNamed[Service](&LayerA{}, Name[Service]("cache", &LayerB{}), Name[Service]("broken-cache", &brokenLayer{}))
+	if err == nil || err.Error() != "layer 'broken-cache': field Service not found" {
+		t.Fatalf("expected the error to use the name of the layer, got %v", err)
+	}
+}
+
+func Test_LayeredNamed_WithCopy(t *testing.T) {
+	layer := &instrumentLayer[string]{}
+	chain, err := LayeredNamedWith[Service](&LayerA{}, []Option{WithCopy()}, Name[Service]("metrics", layer))
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if chain.Value() == Service(layer) {
+		t.Fatalf("expected the layer to be copied")
+	}
+
+	if chain.Describe() != "metrics -> LayerA" {
+		t.Fatalf("expected the copy to keep its name, got %s", chain.Describe())
+	}
+}
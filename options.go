@@ -0,0 +1,225 @@
+package cake
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Option configures how a layered cake is constructed by LayeredWith.
+type Option func(*options)
+
+type options struct {
+	resolver FieldResolver
+	skips    []func(layer any) bool
+	profiler func(d time.Duration, layerCount int)
+	logger   Logger
+	observer BuildObserver
+
+	skipMalformed bool
+	onMalformed   func(err error)
+
+	copyLayers bool
+	shared     map[any]struct{}
+
+	// maxDepth is the maximum number of layers, or -1 for no limit.
+	maxDepth int
+
+	// capacityHint is the expected number of layers, used to size internal slices up front.
+	capacityHint int
+
+	// names holds the names of the layers of a LayeredNamed build, keyed by layer.
+	names map[any]string
+
+	// cache holds the resolved delegate fields of a Builder, keyed by resolveKey.
+	cache *sync.Map
+}
+
+func newOptions(opts []Option) *options {
+	var o = &options{maxDepth: -1}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// FieldResolver returns the index sequence, as accepted by reflect.Value.FieldByIndex, of the
+// field in the struct type layerType that holds the next layer of a cake of interfaceType.
+type FieldResolver func(layerType reflect.Type, interfaceType reflect.Type) ([]int, error)
+
+// WithFieldResolver overrides how cake finds the field of each layer that holds the next layer.
+// By default cake uses the field embedding the interface. This is an escape hatch for layers with
+// unusual struct layouts.
+func WithFieldResolver(resolver FieldResolver) Option {
+	return func(o *options) {
+		o.resolver = resolver
+	}
+}
+
+// WithSkip skips every layer for which skip returns true, as if it were nil. It can be given
+// multiple times, in which case a layer is skipped if any of the funcs returns true.
+func WithSkip(skip func(layer any) bool) Option {
+	return func(o *options) {
+		o.skips = append(o.skips, skip)
+	}
+}
+
+// skip reports whether layer should be skipped by any of the WithSkip funcs.
+func (o *options) skip(layer any) bool {
+	for _, skip := range o.skips {
+		if skip(layer) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithBuildProfiler calls profiler after each successful build with the time it took to wire the
+// cake and the number of layers that were wired, not counting skipped layers or the base.
+func WithBuildProfiler(profiler func(d time.Duration, layerCount int)) Option {
+	return func(o *options) {
+		o.profiler = profiler
+	}
+}
+
+// Logger is the subset of *slog.Logger used by WithSlogger. It keeps cake from depending on
+// log/slog, and lets other structured loggers be adapted with a few lines.
+type Logger interface {
+	Debug(msg string, args ...any)
+}
+
+// WithSlogger logs the composition of each successfully built cake to logger at debug level, with
+// the names of its layers from the outermost inward and the name of its base, as in Describe. The
+// args are key-value pairs as for slog, and a *slog.Logger can be passed as-is.
+func WithSlogger(logger Logger) Option {
+	return func(o *options) {
+		// a nil *slog.Logger would otherwise be a non-nil Logger
+		if val := reflect.ValueOf(logger); val.Kind() == reflect.Ptr && val.IsNil() {
+			logger = nil
+		}
+		o.logger = logger
+	}
+}
+
+// BuildEvent summarizes a successful build for a BuildObserver.
+type BuildEvent struct {
+	// Interface is the name of the interface type of the cake.
+	Interface string
+	// LayerCount is the number of layers that were wired, not counting skipped layers or the base.
+	LayerCount int
+	// Layers holds the names of the layers that were wired, from the outermost inward.
+	Layers []string
+	// Base is the name of the base.
+	Base string
+	// Duration is the time it took to wire the cake.
+	Duration time.Duration
+}
+
+// BuildObserver receives a BuildEvent for every successful build, e.g. to forward it to a
+// telemetry pipeline.
+type BuildObserver interface {
+	ObserveBuild(event BuildEvent)
+}
+
+// WithObserver passes a BuildEvent to observer after each successful build. Builds without an
+// observer don't construct the event.
+func WithObserver(observer BuildObserver) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// WithSkipMalformed makes a build tolerate layers that cannot be wired. Instead of failing, such
+// layers are skipped as if they were nil and the remaining layers are wired around them. Each
+// skipped layer is reported to onMalformed as a *LayerError, onMalformed may be nil. This trades
+// strictness for resilience, for example when the layers come from plugins.
+func WithSkipMalformed(onMalformed func(err error)) Option {
+	return func(o *options) {
+		o.skipMalformed = true
+		o.onMalformed = onMalformed
+	}
+}
+
+// WithCopy wires a shallow copy of each layer instead of the layer itself, leaving the layers that
+// were passed in untouched. This allows the same layer values to be passed to several builds without
+// one build rewiring the layers of another. Layers registered with WithShared are wired as-is.
+func WithCopy() Option {
+	return func(o *options) {
+		o.copyLayers = true
+	}
+}
+
+// WithShared exempts layers from WithCopy, so they are wired by reference. This is meant for layers
+// that are intentional singletons, such as one holding a connection pool. Note that a shared layer
+// is still rewired by each build it is part of, so it should always wrap the same next layer.
+func WithShared(layers ...any) Option {
+	return func(o *options) {
+		if o.shared == nil {
+			o.shared = make(map[any]struct{}, len(layers))
+		}
+		for _, layer := range layers {
+			o.shared[layer] = struct{}{}
+		}
+	}
+}
+
+// copyLayer returns a shallow copy of layer, or layer itself if it is shared.
+func copyLayer[T interface{}](o *options, layer T) T {
+	if _, ok := o.shared[any(layer)]; ok {
+		return layer
+	}
+
+	val := reflect.ValueOf(layer)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return layer
+	}
+
+	cp := reflect.New(val.Elem().Type())
+	cp.Elem().Set(val.Elem())
+	return cp.Interface().(T)
+}
+
+// ErrMaxDepthExceeded is returned by a build with WithMaxDepth that is given too many layers.
+var ErrMaxDepthExceeded = errors.New("maximum depth exceeded")
+
+// WithMaxDepth limits a build to n layers, not counting skipped layers or the base. A build with more
+// layers returns ErrMaxDepthExceeded. This is a safety valve for layers that are assembled from
+// configuration.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// WithCapacityHint sizes the internal slices of a build for n layers up front, so that they don't
+// grow while the layers are collected. This only saves allocations for very large cakes, e.g. with
+// hundreds of layers assembled from configuration.
+func WithCapacityHint(n int) Option {
+	return func(o *options) {
+		o.capacityHint = n
+	}
+}
+
+// Config is a reusable set of options for building cakes of T. Building every cake, including
+// nested sub-cakes, from the same Config ensures they are all built the same way.
+type Config[T interface{}] struct {
+	opts []Option
+}
+
+// NewConfig returns a Config with the given options.
+func NewConfig[T interface{}](opts ...Option) *Config[T] {
+	return &Config[T]{opts: append([]Option(nil), opts...)}
+}
+
+// Options returns the options of the config.
+func (c *Config[T]) Options() []Option {
+	return append([]Option(nil), c.opts...)
+}
+
+// Build calls LayeredWith with the options of the config.
+func (c *Config[T]) Build(base T, layers ...T) (T, error) {
+	return LayeredWith[T](base, c.opts, layers...)
+}
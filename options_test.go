@@ -0,0 +1,377 @@
+package cake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// LayerN delegates through its Next field. The embedded Service is only there to satisfy the
+// interface for methods it doesn't override, and is never set by the custom resolver.
+type LayerN struct {
+	Service
+	Next Service
+}
+
+func (l *LayerN) Fruits() []string {
+	return append(l.Next.Fruits(), "Nectarine")
+}
+
+func (l *LayerN) Veggies() []string {
+	return append(l.Next.Veggies(), "Napa")
+}
+
+func Test_WithFieldResolver(t *testing.T) {
+	var resolver = func(layerType reflect.Type, interfaceType reflect.Type) ([]int, error) {
+		if field, ok := layerType.FieldByName("Next"); ok {
+			return field.Index, nil
+		}
+		field, ok := layerType.FieldByName(interfaceType.Name())
+		if !ok {
+			return nil, fmt.Errorf("no delegate in %s", layerType)
+		}
+		return field.Index, nil
+	}
+
+	layer := &LayerN{}
+	svc, err := LayeredWith[Service](&LayerA{}, []Option{WithFieldResolver(resolver)}, &LayerB{}, layer)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if layer.Service != nil {
+		t.Fatalf("expected the embedded field to be left unset, got %T", layer.Service)
+	}
+
+	expectedFruits := []string{"Apple", "Nectarine", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+}
+
+type fruitsOnly = interface{ Fruits() []string }
+
+type anonLayer struct {
+	Next interface{ Fruits() []string }
+}
+
+func (l *anonLayer) Fruits() []string {
+	return append(l.Next.Fruits(), "Anonymous")
+}
+
+type ambiguousAnonLayer struct {
+	Next     interface{ Fruits() []string }
+	Fallback interface{ Fruits() []string }
+}
+
+func (l *ambiguousAnonLayer) Fruits() []string {
+	return l.Next.Fruits()
+}
+
+func Test_Layered_AnonymousInterface(t *testing.T) {
+	svc, err := Layered[interface{ Fruits() []string }](&LayerA{}, &anonLayer{}, &anonLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Anonymous", "Anonymous"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	_, err = Layered[fruitsOnly](&LayerA{}, &ambiguousAnonLayer{})
+	if err == nil || !strings.Contains(err.Error(), "more than one field") {
+		t.Fatalf("expected an ambiguous field error, got %v", err)
+	}
+}
+
+func Test_Config(t *testing.T) {
+	var skipped int
+	config := NewConfig[Service](WithSkip(func(layer any) bool {
+		_, ok := layer.(*LayerC)
+		if ok {
+			skipped++
+		}
+		return ok
+	}))
+
+	inner, err := config.Build(&LayerA{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	outer, err := config.Build(inner, &LayerB{}, &LayerC{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if skipped != 2 {
+		t.Fatalf("expected the skip func to apply to both builds, got %d skips", skipped)
+	}
+
+	expectedVeggies := []string{"Artichoke", "Dill", "Basil"}
+	if fmt.Sprint(outer.Veggies()) != fmt.Sprint(expectedVeggies) {
+		t.Fatalf("expectedVeggies %v, got %v", expectedVeggies, outer.Veggies())
+	}
+}
+
+func Test_WithBuildProfiler(t *testing.T) {
+	var calls int
+	var duration time.Duration
+	var count int
+	profiler := WithBuildProfiler(func(d time.Duration, layerCount int) {
+		calls++
+		duration, count = d, layerCount
+	})
+
+	_, err := LayeredWith[Service](&LayerA{}, []Option{profiler}, &LayerB{}, If(false, &LayerC{}), &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected the profiler to be called once, got %d", calls)
+	}
+
+	if count != 2 {
+		t.Fatalf("expected 2 layers, got %d", count)
+	}
+
+	if duration < 0 || duration > time.Second {
+		t.Fatalf("expected a plausible duration, got %s", duration)
+	}
+}
+
+// recordHandler records every slog.Record it handles.
+type recordHandler struct {
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func Test_WithSlogger(t *testing.T) {
+	handler := &recordHandler{}
+	opts := []Option{WithSlogger(slog.New(handler))}
+
+	_, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, If(false, &LayerC{}), &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if len(handler.records) != 1 {
+		t.Fatalf("expected one record, got %d", len(handler.records))
+	}
+
+	record := handler.records[0]
+	if record.Level != slog.LevelDebug {
+		t.Fatalf("expected level %s, got %s", slog.LevelDebug, record.Level)
+	}
+
+	var attrs = make(map[string]string)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs[attr.Key] = fmt.Sprint(attr.Value.Any())
+		return true
+	})
+
+	if attrs["layers"] != "[LayerB LayerD]" {
+		t.Fatalf("expected layers [LayerB LayerD], got %s", attrs["layers"])
+	}
+
+	if attrs["base"] != "LayerA" {
+		t.Fatalf("expected base LayerA, got %s", attrs["base"])
+	}
+
+	var nilLogger *slog.Logger
+	if _, err := LayeredWith[Service](&LayerA{}, []Option{WithSlogger(nilLogger)}, &LayerB{}); err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+}
+
+// debugRecorder is a Logger that isn't a *slog.Logger.
+type debugRecorder struct {
+	messages []string
+}
+
+func (r *debugRecorder) Debug(msg string, args ...any) {
+	r.messages = append(r.messages, fmt.Sprintf("%s %v", msg, args))
+}
+
+func Test_WithSlogger_Logger(t *testing.T) {
+	logger := &debugRecorder{}
+	if _, err := LayeredWith[Service](&LayerA{}, []Option{WithSlogger(logger)}, &LayerB{}); err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if len(logger.messages) != 1 || logger.messages[0] != "layered cake [layers [LayerB] base LayerA]" {
+		t.Fatalf("expected one debug message, got %v", logger.messages)
+	}
+}
+
+func Test_WithSkipMalformed(t *testing.T) {
+	var errs []error
+	opts := []Option{WithSkipMalformed(func(err error) { errs = append(errs, err) })}
+
+	svc, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, &brokenLayer{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	var layerErr *LayerError
+	if len(errs) != 1 || !errors.As(errs[0], &layerErr) || layerErr.Index != 1 {
+		t.Fatalf("expected one LayerError for layer 1, got %v", errs)
+	}
+
+	if _, err := Layered[Service](&LayerA{}, &LayerB{}, &brokenLayer{}, &LayerD{}); err == nil {
+		t.Fatalf("expected an error without WithSkipMalformed")
+	}
+
+	errs = nil
+	svc, err = LayeredWith[Service](&LayerA{}, opts, &LayerB{}, &stringFieldLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], ErrFieldTypeMismatch) {
+		t.Fatalf("expected the mismatched layer to be skipped, got %v", errs)
+	}
+}
+
+func Test_WithCopy(t *testing.T) {
+	shared := &LayerB{}
+	copied := &LayerD{}
+	opts := []Option{WithCopy(), WithShared(shared)}
+
+	first, err := LayeredWith[Service](&LayerA{}, opts, shared, copied)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+	firstInner := Layers(first)[1]
+
+	second, err := LayeredWith[Service](&LayerA{}, opts, shared, copied)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if first != Service(shared) || second != Service(shared) {
+		t.Fatalf("expected the shared layer to be wired by reference in both builds")
+	}
+
+	secondInner := Layers(second)[1]
+	if firstInner == Service(copied) || secondInner == Service(copied) || firstInner == secondInner {
+		t.Fatalf("expected each build to wire its own copy of the other layers")
+	}
+
+	if copied.Service != nil {
+		t.Fatalf("expected the original layer to be left unwired, got %T", copied.Service)
+	}
+
+	expectedFruits := []string{"Apple", "Durian", "Banana"}
+	if fmt.Sprint(second.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, second.Fruits())
+	}
+}
+
+// resourceLayer releases its resource when it is skipped.
+type resourceLayer struct {
+	Service
+	released bool
+}
+
+func (l *resourceLayer) OnSkip() { l.released = true }
+
+func Test_Skippable(t *testing.T) {
+	skipped := &resourceLayer{}
+	wired := &resourceLayer{}
+	opts := []Option{WithSkip(func(layer any) bool { return layer == Service(skipped) })}
+
+	_, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, skipped, wired, (*resourceLayer)(nil))
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if !skipped.released {
+		t.Fatalf("expected OnSkip to be called for the skipped layer")
+	}
+
+	if wired.released {
+		t.Fatalf("expected OnSkip not to be called for a wired layer")
+	}
+}
+
+func Test_WithMaxDepth(t *testing.T) {
+	opts := []Option{WithMaxDepth(2)}
+
+	if _, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, nil, &LayerD{}); err != nil {
+		t.Fatalf("expected a build at the limit to succeed, got %v", err)
+	}
+
+	_, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, &LayerC{}, &LayerD{})
+	if !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Fatalf("expected %v, got %v", ErrMaxDepthExceeded, err)
+	}
+
+	if err.Error() != "maximum depth exceeded: 3 layers, at most 2 allowed" {
+		t.Fatalf("expected a descriptive error, got %q", err)
+	}
+}
+
+// eventRecorder records every BuildEvent it observes.
+type eventRecorder struct {
+	events []BuildEvent
+}
+
+func (r *eventRecorder) ObserveBuild(event BuildEvent) {
+	r.events = append(r.events, event)
+}
+
+func Test_WithObserver(t *testing.T) {
+	recorder := &eventRecorder{}
+	opts := []Option{WithObserver(recorder)}
+
+	_, err := LayeredWith[Service](&LayerA{}, opts, &LayerB{}, If(false, &LayerC{}), &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if len(recorder.events) != 1 {
+		t.Fatalf("expected one event, got %d", len(recorder.events))
+	}
+
+	event := recorder.events[0]
+	if event.Interface != "cake.Service" || event.LayerCount != 2 || event.Base != "LayerA" {
+		t.Fatalf("expected a fully populated event, got %+v", event)
+	}
+
+	if fmt.Sprint(event.Layers) != "[LayerB LayerD]" {
+		t.Fatalf("expected layers [LayerB LayerD], got %v", event.Layers)
+	}
+
+	if event.Duration < 0 {
+		t.Fatalf("expected a non-negative duration, got %s", event.Duration)
+	}
+
+	if _, err := LayeredWith[Service](&LayerA{}, opts, &brokenLayer{}); err == nil || len(recorder.events) != 1 {
+		t.Fatalf("expected no event for a failed build")
+	}
+}
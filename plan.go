@@ -0,0 +1,70 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Plan returns the wiring plan Layered would carry out for base and layers, without modifying any
+// of the layers. Each line of the plan names a layer and the field that would be set to the next
+// layer, e.g.:
+//
+//	LayerB.Service = LayerC
+//	LayerC.Service = base
+//
+// Layers that would be wired through NextSetter appear as calls to SetNext, and the delegate of a
+// Terminal layer over a nil base is planned as nil. Plan runs the same checks as Layered and returns
+// the same errors it would. Unlike Layered, it never calls OnSkip on the layers it skips.
+func Plan[T interface{}](base T, layers ...T) (string, error) {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+
+	if err := checkInterface[T](); err != nil {
+		return "", err
+	}
+
+	var valid, indices = collect(o, layers)
+	if len(valid) == 0 {
+		return "", nil
+	}
+
+	if err := validateConstraints(base, valid, indices); err != nil {
+		return "", err
+	} else if err := checkBase(base, valid); err != nil {
+		return "", err
+	}
+
+	var hasBase = reflect.ValueOf(base).IsValid()
+	_, setters := nextSetters(valid)
+
+	var lines = make([]string, 0, len(valid))
+	for i, layer := range valid {
+		var next, nextName = reflect.ValueOf(base), "base"
+		if i < len(valid)-1 {
+			next, nextName = reflect.ValueOf(valid[i+1]), layerName(valid[i+1])
+		} else if !hasBase {
+			nextName = "nil"
+		}
+
+		if setters {
+			lines = append(lines, fmt.Sprintf("%s.SetNext(%s)", layerName(layer), nextName))
+			continue
+		}
+
+		info, err := o.inspectLayer(layer, interfaceType)
+		if err != nil {
+			return "", &LayerError{Index: indices[i], Layer: layer, Err: err}
+		}
+
+		// the delegate of a Terminal layer over a nil base is set to the zero value of its field
+		if next.IsValid() {
+			if err := info.check(next); err != nil {
+				return "", &LayerError{Index: indices[i], Layer: layer, Err: err}
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s.%s = %s", layerName(layer), info.name, nextName))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
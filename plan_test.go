@@ -0,0 +1,118 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_Plan(t *testing.T) {
+	b, c, d := &LayerB{}, &LayerC{}, &LayerD{}
+	plan, err := Plan[Service](&LayerA{}, b, If(false, &LayerE{}), c, d)
+	if err != nil {
+		t.Fatalf("failed to plan cake: %+v", err)
+	}
+
+	expected := "LayerB.Service = LayerC\nLayerC.Service = LayerD\nLayerD.Service = base"
+	if plan != expected {
+		t.Fatalf("expected plan:\n%s\ngot:\n%s", expected, plan)
+	}
+
+	if b.Service != nil || c.Service != nil || d.Service != nil {
+		t.Fatalf("expected the layers not to be modified")
+	}
+}
+
+func Test_Plan_NextSetter(t *testing.T) {
+	plan, err := Plan[Service](&LayerA{}, &setterLayer{}, &setterLayer{})
+	if err != nil {
+		t.Fatalf("failed to plan cake: %+v", err)
+	}
+
+	expected := "setterLayer.SetNext(setterLayer)\nsetterLayer.SetNext(base)"
+	if plan != expected {
+		t.Fatalf("expected plan:\n%s\ngot:\n%s", expected, plan)
+	}
+}
+
+func Test_Plan_Error(t *testing.T) {
+	_, err := Plan[Service](&LayerA{}, &LayerB{}, &brokenLayer{})
+
+	var layerErr *LayerError
+	if !errors.As(err, &layerErr) || layerErr.Index != 1 {
+		t.Fatalf("expected a LayerError for layer 1, got %v", err)
+	}
+}
+
+// disabledResourceLayer is disabled and releases its resource when it is skipped.
+type disabledResourceLayer struct {
+	Service
+	released bool
+}
+
+func (l *disabledResourceLayer) Enabled() bool { return false }
+func (l *disabledResourceLayer) OnSkip()       { l.released = true }
+
+func Test_Plan_DoesNotNotifySkipped(t *testing.T) {
+	layer := &disabledResourceLayer{}
+	if _, err := Plan[Service](&LayerA{}, &LayerB{}, layer); err != nil {
+		t.Fatalf("failed to plan cake: %+v", err)
+	}
+
+	if layer.released {
+		t.Fatalf("expected Plan not to call OnSkip")
+	}
+
+	if _, err := Layered[Service](&LayerA{}, &LayerB{}, layer); err != nil || !layer.released {
+		t.Fatalf("expected Layered to call OnSkip, got %v", err)
+	}
+}
+
+func Test_Plan_SameErrorsAsLayered(t *testing.T) {
+	testTable := map[string]struct {
+		base        Service
+		layers      func() []Service
+		expectedErr string
+	}{
+		"Nil base": {
+			base:        nil,
+			layers:      func() []Service { return []Service{&LayerB{}} },
+			expectedErr: ErrInvalidBase.Error(),
+		},
+		"Nil base under a Terminal layer": {
+			base:   nil,
+			layers: func() []Service { return []Service{&LayerB{}, &terminalLayer{}} },
+		},
+		"Missing field": {
+			base:        &LayerA{},
+			layers:      func() []Service { return []Service{&LayerB{}, &brokenLayer{}} },
+			expectedErr: "field Service not found",
+		},
+		"Field of the wrong type": {
+			base:        &LayerA{},
+			layers:      func() []Service { return []Service{&LayerB{}, &stringFieldLayer{}} },
+			expectedErr: "field type mismatch",
+		},
+		"Inner layer of the wrong version": {
+			base:        &LayerA{},
+			layers:      func() []Service { return []Service{&v2DelegateLayer{}, &LayerB{}} },
+			expectedErr: "field type mismatch",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, planErr := Plan[Service](testCase.base, testCase.layers()...)
+			_, layeredErr := Layered[Service](testCase.base, testCase.layers()...)
+			if fmt.Sprint(planErr) != fmt.Sprint(layeredErr) || !strings.Contains(fmt.Sprint(planErr), testCase.expectedErr) {
+				t.Fatalf("expected Plan to return %v, got %v", layeredErr, planErr)
+			}
+		})
+	}
+
+	_, planErr := Plan[any](&LayerA{}, &LayerB{})
+	_, layeredErr := Layered[any](&LayerA{}, &LayerB{})
+	if !errors.Is(planErr, ErrEmptyInterface) || planErr.Error() != layeredErr.Error() {
+		t.Fatalf("expected Plan to return %v, got %v", layeredErr, planErr)
+	}
+}
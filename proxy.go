@@ -0,0 +1,575 @@
+package cake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoProxy is returned by the proxy-based helpers when no proxy has been registered for the
+// interface type with RegisterProxy.
+var ErrNoProxy = errors.New("no proxy registered")
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// proxies maps an interface reflect.Type to a constructor of its registered proxy.
+var proxies sync.Map
+
+// RegisterProxy registers a constructor for a proxy of interface T. Go cannot create new types
+// that implement an interface at runtime, so the proxy-based helpers in this package need a
+// stand-in for T. The proxy must be a pointer to a struct that has an exported func field for
+// each method of T, named after the method with a "Func" suffix, and whose methods simply call
+// those fields:
+//
+//	type serviceProxy struct {
+//	    GetMessageFunc func(ctx context.Context, id string) string
+//	}
+//
+//	func (p *serviceProxy) GetMessage(ctx context.Context, id string) string {
+//	    return p.GetMessageFunc(ctx, id)
+//	}
+//
+// The func fields of each new proxy are populated with reflect.MakeFunc. Mocks generated by moq
+// follow this layout and can be registered as-is.
+func RegisterProxy[T interface{}](newProxy func() T) {
+	proxies.Store(TypeOf[T](), func() any { return newProxy() })
+}
+
+// handlerFunc handles a single method call made on a proxy.
+type handlerFunc func(method reflect.Method, args []reflect.Value) []reflect.Value
+
+// newProxy constructs the registered proxy for T and points the func field of each method at
+// handler.
+func newProxy[T interface{}](handler handlerFunc) (T, error) {
+	var ifaceType = TypeOf[T]()
+
+	ctor, ok := proxies.Load(ifaceType)
+	if !ok {
+		return *new(T), fmt.Errorf("%w for %s", ErrNoProxy, ifaceType)
+	}
+
+	proxy := ctor.(func() any)()
+	val := reflect.ValueOf(proxy)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return *new(T), fmt.Errorf("proxy '%T' for %s must be a pointer to a struct", proxy, ifaceType)
+	}
+
+	for i := 0; i < ifaceType.NumMethod(); i++ {
+		method := ifaceType.Method(i)
+
+		field := val.Elem().FieldByName(method.Name + "Func")
+		if !field.IsValid() || !field.CanSet() {
+			return *new(T), fmt.Errorf("field %sFunc in proxy '%T' cannot be set", method.Name, proxy)
+		} else if field.Type() != method.Type {
+			return *new(T), fmt.Errorf("field %sFunc in proxy '%T' has type %s, expected %s", method.Name, proxy, field.Type(), method.Type)
+		}
+
+		field.Set(reflect.MakeFunc(method.Type, func(args []reflect.Value) (results []reflect.Value) {
+			defer func() {
+				if r := recover(); r != nil {
+					h, ok := r.(*halt)
+					if !ok {
+						panic(r)
+					}
+					results = h.values(method)
+				}
+			}()
+			return handler(method, args)
+		}))
+	}
+
+	return proxy.(T), nil
+}
+
+// halt is the value Halt panics with to unwind a call made through a proxy.
+type halt struct {
+	results []any
+}
+
+func (h *halt) Error() string {
+	return "cake: Halt called outside of a proxy"
+}
+
+// values converts the results given to Halt to the return values of method.
+func (h *halt) values(method reflect.Method) []reflect.Value {
+	if len(h.results) != method.Type.NumOut() {
+		panic(fmt.Sprintf("cake: Halt: method %s returns %d values, got %d", method.Name, method.Type.NumOut(), len(h.results)))
+	}
+
+	var values = make([]reflect.Value, len(h.results))
+	for i, result := range h.results {
+		out := method.Type.Out(i)
+		if result == nil {
+			values[i] = reflect.Zero(out)
+			continue
+		}
+
+		val := reflect.ValueOf(result)
+		if !val.Type().AssignableTo(out) {
+			panic(fmt.Sprintf("cake: Halt: result %d of method %s must be %s, got %T", i, method.Name, out, result))
+		}
+		values[i] = reflect.New(out).Elem()
+		values[i].Set(val)
+	}
+	return values
+}
+
+// Halt short-circuits a call made through one of the proxies in this package: the proxy returns
+// the given results without calling into the chain, or into any further layer. It may only be
+// called from a func run by a proxy, such as the rewrite func of Intercept. The results must match
+// the return values of the method being called, with nil standing in for a zero value.
+//
+// Layers wired with Layered don't need Halt, they short-circuit by returning without calling
+// their next layer.
+func Halt(results ...any) {
+	panic(&halt{results: results})
+}
+
+// chainValue returns the reflect.Value of chain, or an error if chain is nil.
+func chainValue[T interface{}](chain T) (reflect.Value, error) {
+	val := reflect.ValueOf(chain)
+	if !val.IsValid() || (val.Kind() == reflect.Ptr && val.IsNil()) {
+		return val, fmt.Errorf("chain of type %s must not be nil", TypeOf[T]())
+	}
+	return val, nil
+}
+
+// call invokes method on target with the given arguments.
+func call(target reflect.Value, method reflect.Method, args []reflect.Value) []reflect.Value {
+	return callFunc(target.MethodByName(method.Name), args)
+}
+
+// callFunc invokes fn with the arguments passed to a func made by reflect.MakeFunc, which hold
+// variadic arguments as a single slice.
+func callFunc(fn reflect.Value, args []reflect.Value) []reflect.Value {
+	if fn.Type().IsVariadic() {
+		return fn.CallSlice(args)
+	}
+	return fn.Call(args)
+}
+
+// returnsError reports whether the last return value of the func type t is an error.
+func returnsError(t reflect.Type) bool {
+	return t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+}
+
+// errorResults returns the zero value for each return value of method, except for the last one
+// which is set to err. method must return an error as its last value.
+func errorResults(method reflect.Method, err error) []reflect.Value {
+	var results = make([]reflect.Value, method.Type.NumOut())
+	for i := range results {
+		results[i] = reflect.Zero(method.Type.Out(i))
+	}
+	if err != nil {
+		results[len(results)-1] = reflect.ValueOf(&err).Elem()
+	}
+	return results
+}
+
+// contextArg returns the context.Context passed as the first argument of a call to method, if the
+// method takes one. The proxies in this package pass every argument through as-is, so a context
+// given to a proxy reaches each layer of the chain.
+func contextArg(method reflect.Method, args []reflect.Value) (context.Context, bool) {
+	if method.Type.NumIn() == 0 || method.Type.In(0) != contextType || len(args) == 0 {
+		return nil, false
+	}
+	ctx, _ := args[0].Interface().(context.Context)
+	return ctx, ctx != nil
+}
+
+// ShortCircuit returns a proxy around chain for methods that return an error as their last value.
+// As soon as any value of the chain returns a non-nil error, the call unwinds straight back to the
+// proxy without returning through the layers outward of it, so none of their post-processing or
+// side effects run. The proxy returns the error, replacing every other return value with its zero
+// value so that no partial result leaks out to the caller. Methods that do not return an error are
+// passed through.
+//
+// Like WithLayerTiming, ShortCircuit puts a proxy in front of every value inward of the outermost
+// layer, in a shallow copy of chain. The layers of chain are left untouched. Layers must call their
+// next layer on the goroutine of the call for it to be unwound. ShortCircuit requires a proxy
+// registered for T with RegisterProxy.
+func ShortCircuit[T interface{}](chain T) (T, error) {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+
+	if _, err := chainValue(chain); err != nil {
+		return *new(T), err
+	}
+
+	layers, base, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for i := range layers {
+		layers[i] = copyLayer(o, layers[i])
+	}
+	values := append(layers, base)
+
+	var circuit = &circuit{}
+	for i, layer := range layers {
+		hop, err := circuitHop(circuit, values[i+1])
+		if err != nil {
+			return *new(T), err
+		}
+
+		info, err := o.inspectLayer(layer, interfaceType)
+		if err != nil {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: err}
+		} else if err := info.set(reflect.ValueOf(hop)); err != nil {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: err}
+		}
+	}
+
+	target := reflect.ValueOf(values[0])
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) (results []reflect.Value) {
+		if !returnsError(method.Type) {
+			return call(target, method, args)
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				t, ok := r.(*trip)
+				if !ok || t.circuit != circuit {
+					panic(r)
+				}
+				results = errorResults(method, t.err)
+			}
+		}()
+
+		results = call(target, method, args)
+		if err := results[len(results)-1]; !err.IsNil() {
+			return errorResults(method, err.Interface().(error))
+		}
+		return results
+	})
+}
+
+// circuit identifies the calls unwound by one ShortCircuit proxy.
+type circuit struct{}
+
+// trip is the value a hop of a circuit panics with to unwind a call to the ShortCircuit proxy.
+type trip struct {
+	circuit *circuit
+	err     error
+}
+
+// circuitHop returns a proxy in front of value that unwinds the call to the ShortCircuit proxy of c
+// when value returns an error.
+func circuitHop[T interface{}](c *circuit, value T) (T, error) {
+	target := reflect.ValueOf(value)
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		results := call(target, method, args)
+		if returnsError(method.Type) {
+			if err := results[len(results)-1]; !err.IsNil() {
+				panic(&trip{circuit: c, err: err.Interface().(error)})
+			}
+		}
+		return results
+	})
+}
+
+// WithRetry returns a proxy around chain that calls a method again, up to attempts times in total,
+// for as long as its last return value is an error for which shouldRetry returns true. Methods that
+// do not return an error are passed through and called once.
+//
+// For methods that take a context.Context as their first argument, the proxy stops retrying once the
+// context is done.
+//
+// Every call made through the proxy goes through reflect.Value.Call, which is considerably slower
+// than a plain method call and allocates. This is usually negligible compared to a call that is
+// worth retrying, but the proxy should not be used on hot paths that never fail.
+func WithRetry[T interface{}](chain T, attempts int, shouldRetry func(error) bool) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	} else if attempts < 1 {
+		return *new(T), fmt.Errorf("attempts must be at least 1, got %d", attempts)
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		results := call(target, method, args)
+		if !returnsError(method.Type) {
+			return results
+		}
+
+		for attempt := 1; attempt < attempts; attempt++ {
+			err, _ := results[len(results)-1].Interface().(error)
+			if err == nil || !shouldRetry(err) {
+				break
+			} else if ctx, ok := contextArg(method, args); ok && ctx.Err() != nil {
+				break
+			}
+			results = call(target, method, args)
+		}
+		return results
+	})
+}
+
+// LazyBase returns a proxy for T that calls baseFn the first time any of its methods is called,
+// and delegates every call to the value it returned. baseFn is called at most once, even when the
+// proxy is used concurrently. This is useful for passing an expensive base to Layered that should
+// only be constructed once a call actually reaches it.
+//
+// LazyBase panics if no proxy is registered for T.
+func LazyBase[T interface{}](baseFn func() T) T {
+	var once sync.Once
+	var base reflect.Value
+
+	proxy, err := newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		once.Do(func() {
+			base = reflect.ValueOf(baseFn())
+		})
+		return call(base, method, args)
+	})
+	if err != nil {
+		panic(fmt.Sprintf("cake: LazyBase: %v", err))
+	}
+
+	return proxy
+}
+
+// Intercept returns a proxy around chain that passes the name and arguments of every call to
+// rewrite before delegating to chain with the arguments it returns. This is useful for normalizing
+// requests before any layer sees them. The proxy panics if rewrite returns arguments that don't
+// match the parameters of the method.
+func Intercept[T interface{}](chain T, rewrite func(method string, args []reflect.Value) []reflect.Value) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		args = rewrite(method.Name, args)
+		if err := checkArgs(method, args); err != nil {
+			panic(fmt.Sprintf("cake: Intercept: %v", err))
+		}
+		return call(target, method, args)
+	})
+}
+
+// checkArgs returns an error if args cannot be used to call method.
+func checkArgs(method reflect.Method, args []reflect.Value) error {
+	if len(args) != method.Type.NumIn() {
+		return fmt.Errorf("method %s takes %d arguments, got %d", method.Name, method.Type.NumIn(), len(args))
+	}
+
+	for i, arg := range args {
+		if !arg.IsValid() || !arg.Type().AssignableTo(method.Type.In(i)) {
+			return fmt.Errorf("argument %d of method %s must be %s, got %v", i, method.Name, method.Type.In(i), arg)
+		}
+	}
+	return nil
+}
+
+// Route returns a proxy for T that dispatches each method call to the chain registered for the
+// method's name in routes, or to fallback for methods without a route. This is useful for sending
+// reads and writes through different stacks of layers. It returns an error if a route names a
+// method that T does not have, or if any chain is nil.
+func Route[T interface{}](routes map[string]T, fallback T) (T, error) {
+	var interfaceType = TypeOf[T]()
+	var methods = MethodSet[T]()
+
+	target, err := chainValue(fallback)
+	if err != nil {
+		return *new(T), fmt.Errorf("fallback: %w", err)
+	}
+
+	var targets = make(map[string]reflect.Value, len(routes))
+	for name, chain := range routes {
+		if !inMethodSet(methods, name) {
+			return *new(T), fmt.Errorf("route %s: %s has no method %s, expected one of %s", name, interfaceType, name, strings.Join(methods, ", "))
+		}
+
+		val, err := chainValue(chain)
+		if err != nil {
+			return *new(T), fmt.Errorf("route %s: %w", name, err)
+		}
+		targets[name] = val
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if val, ok := targets[method.Name]; ok {
+			return call(val, method, args)
+		}
+		return call(target, method, args)
+	})
+}
+
+// WithPanicBoundary returns a proxy around chain that recovers a panic raised anywhere in the chain
+// during a method call. The recovered value is passed to onPanic, and for methods that return an
+// error as their last value the proxy returns the error from onPanic, with every other return value
+// set to its zero value. Methods that do not return an error re-panic with the recovered value after
+// onPanic has been called.
+//
+// Like the other proxies in this package every method of the proxy is a func made with
+// reflect.MakeFunc, so a single deferred recover covers every method of T without generated code.
+func WithPanicBoundary[T interface{}](chain T, onPanic func(recovered any) error) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) (results []reflect.Value) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			} else if _, ok := r.(*halt); ok {
+				panic(r)
+			}
+
+			err := onPanic(r)
+			if !returnsError(method.Type) {
+				panic(r)
+			}
+
+			results = errorResults(method, err)
+		}()
+		return call(target, method, args)
+	})
+}
+
+// DisableMethod returns a proxy around chain that calls the named method directly on the base of
+// chain, bypassing every layer, while every other method goes through the whole chain. It returns an
+// error if T has no such method.
+func DisableMethod[T interface{}](chain T, method string) (T, error) {
+	var interfaceType = TypeOf[T]()
+
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	} else if !inMethodSet(MethodSet[T](), method) {
+		return *new(T), fmt.Errorf("%s has no method %s", interfaceType, method)
+	}
+
+	_, innermost, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	base, err := chainValue(innermost)
+	if err != nil {
+		return *new(T), fmt.Errorf("base: %w", err)
+	}
+
+	return newProxy[T](func(m reflect.Method, args []reflect.Value) []reflect.Value {
+		if m.Name == method {
+			return call(base, m, args)
+		}
+		return call(target, m, args)
+	})
+}
+
+// Toggleable returns a proxy that stands in for layer in a cake, along with the flag that enables
+// it, which starts out true. The proxy can be passed to Layered in place of layer, which wires the
+// delegate field of layer as usual. While the flag is true every call is made on layer, and while it
+// is false every call is made on the next layer directly, skipping the logic of layer. This lets a
+// layer be switched off at runtime without building the cake again. The proxy is treated as layer
+// wherever cake looks at a layer, so layer is still named, configured, closed and told its depth
+// as if it had been passed itself.
+//
+// Toggleable panics if no proxy is registered for T.
+func Toggleable[T interface{}](layer T) (T, *atomic.Bool) {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+	var enabled = &atomic.Bool{}
+	enabled.Store(true)
+
+	target := reflect.ValueOf(layer)
+	proxy, err := newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		if enabled.Load() {
+			return call(target, method, args)
+		}
+
+		info, err := o.inspectLayer(layer, interfaceType)
+		if err != nil {
+			panic(fmt.Sprintf("cake: Toggleable: layer '%T' cannot be skipped: %v", layer, err))
+		}
+		return call(info.field.Elem(), method, args)
+	})
+	if err != nil {
+		panic(fmt.Sprintf("cake: Toggleable: %v", err))
+	}
+
+	registerStandIn(proxy, layer)
+	return proxy, enabled
+}
+
+// WithTimeout returns a proxy around chain that bounds every call of a method that takes a
+// context.Context as its first argument to d. The context passed to chain is derived from the one
+// given to the proxy, so it is done once d has passed or once the caller's context is done, whichever
+// comes first. Methods that don't take a context are passed through. Layers are expected to honor
+// the context; the proxy does not abandon a call that ignores it.
+func WithTimeout[T interface{}](chain T, d time.Duration) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		ctx, ok := contextArg(method, args)
+		if !ok {
+			return call(target, method, args)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, args[1:]...)
+		return call(target, method, args)
+	})
+}
+
+// SelfWrapping returns a proxy around chain for interfaces with fluent methods, whose only return
+// value is a T. The value such a method returns is usually created by the base, without any of the
+// layers of chain. The proxy re-applies copies of the layers to it with RewrapResult, and returns
+// it behind another SelfWrapping proxy so that the layers also survive the next fluent call. A
+// result that already is one of the layers of chain is returned as-is. Other methods are passed
+// through.
+//
+// This only applies to calls made through the proxy, and only to methods that return exactly one
+// value of type T.
+func SelfWrapping[T interface{}](chain T) (T, error) {
+	var interfaceType = TypeOf[T]()
+
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	layers, _, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		results := call(target, method, args)
+		if method.Type.NumOut() != 1 || method.Type.Out(0) != interfaceType || results[0].IsNil() {
+			return results
+		}
+
+		result, _ := results[0].Interface().(T)
+		for _, layer := range layers {
+			if any(layer) == any(result) {
+				return results
+			}
+		}
+
+		wrapped, err := RewrapResult(chain, result)
+		if err == nil {
+			wrapped, err = SelfWrapping(wrapped)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("cake: SelfWrapping: method %s: %v", method.Name, err))
+		}
+		return []reflect.Value{reflect.ValueOf(&wrapped).Elem()}
+	})
+}
@@ -0,0 +1,601 @@
+package cake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	RegisterProxy[Service](func() Service { return &serviceProxy{} })
+	RegisterProxy[Store](func() Store { return &storeProxy{} })
+	RegisterProxy[ServiceV2](func() ServiceV2 { return &serviceV2Proxy{} })
+	RegisterProxy[Doer](func() Doer { return &doerProxy{} })
+	RegisterProxy[Greeter](func() Greeter { return &greeterProxy{} })
+}
+
+type serviceProxy struct {
+	FruitsFunc  func() []string
+	VeggiesFunc func() []string
+}
+
+func (p *serviceProxy) Fruits() []string  { return p.FruitsFunc() }
+func (p *serviceProxy) Veggies() []string { return p.VeggiesFunc() }
+
+// Store is a fallible interface used to exercise the proxies that care about errors.
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, value string) error
+	Len() int
+}
+
+type storeProxy struct {
+	GetFunc func(key string) (string, error)
+	PutFunc func(key, value string) error
+	LenFunc func() int
+}
+
+func (p *storeProxy) Get(key string) (string, error) { return p.GetFunc(key) }
+func (p *storeProxy) Put(key, value string) error    { return p.PutFunc(key, value) }
+func (p *storeProxy) Len() int                       { return p.LenFunc() }
+
+var errStore = errors.New("store failure")
+
+type mapStore struct{ values map[string]string }
+
+func (s *mapStore) Get(key string) (string, error) {
+	if v, ok := s.values[key]; ok {
+		return v, nil
+	}
+	return "", errStore
+}
+
+func (s *mapStore) Put(key, value string) error {
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	s.values[key] = value
+	return nil
+}
+
+func (s *mapStore) Len() int { return len(s.values) }
+
+// partialLayer returns a partial result alongside the error of its delegate.
+type partialLayer struct{ Store }
+
+func (l *partialLayer) Get(key string) (string, error) {
+	v, err := l.Store.Get(key)
+	if err != nil {
+		return "partial:" + key, err
+	}
+	return v, nil
+}
+
+// auditStoreLayer records every key its delegate returned into a shared log, error or not.
+type auditStoreLayer struct {
+	Store
+	log *[]string
+}
+
+func (l *auditStoreLayer) Get(key string) (string, error) {
+	v, err := l.Store.Get(key)
+	*l.log = append(*l.log, key)
+	return v, err
+}
+
+func Test_ShortCircuit(t *testing.T) {
+	var log []string
+	chain, err := Layered[Store](&mapStore{values: map[string]string{"a": "apple"}}, &auditStoreLayer{log: &log}, &partialLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+	described := Describe(chain)
+
+	svc, err := ShortCircuit(chain)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if Describe(chain) != described {
+		t.Fatalf("expected chain to be left untouched, got %s", Describe(chain))
+	}
+
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected apple, got %q (%v)", v, err)
+	}
+
+	v, err := svc.Get("b")
+	if !errors.Is(err, errStore) {
+		t.Fatalf("expected %v, got %v", errStore, err)
+	}
+	if v != "" {
+		t.Fatalf("expected the partial result to be dropped, got %q", v)
+	}
+
+	if fmt.Sprint(log) != "[a]" {
+		t.Fatalf("expected the outer layer not to run after the error, got %v", log)
+	}
+
+	if n := svc.Len(); n != 1 {
+		t.Fatalf("expected Len to pass through, got %d", n)
+	}
+}
+
+func Test_ShortCircuit_NoProxy(t *testing.T) {
+	type unregistered interface{ Fruits() []string }
+
+	_, err := ShortCircuit[unregistered](&LayerA{})
+	if !errors.Is(err, ErrNoProxy) {
+		t.Fatalf("expected %v, got %v", ErrNoProxy, err)
+	}
+}
+
+// flakyLayer fails the first n calls to Get.
+type flakyLayer struct {
+	Store
+	n     int
+	calls int
+}
+
+func (l *flakyLayer) Get(key string) (string, error) {
+	l.calls++
+	if l.calls <= l.n {
+		return "", errStore
+	}
+	return l.Store.Get(key)
+}
+
+func Test_WithRetry(t *testing.T) {
+	testTable := map[string]struct {
+		attempts      int
+		shouldRetry   func(error) bool
+		expectedErr   error
+		expectedCalls int
+	}{
+		"Retries until the call succeeds": {
+			attempts:      3,
+			shouldRetry:   func(err error) bool { return errors.Is(err, errStore) },
+			expectedErr:   nil,
+			expectedCalls: 3,
+		},
+		"Gives up after the given number of attempts": {
+			attempts:      2,
+			shouldRetry:   func(err error) bool { return errors.Is(err, errStore) },
+			expectedErr:   errStore,
+			expectedCalls: 2,
+		},
+		"Does not retry errors that shouldRetry rejects": {
+			attempts:      3,
+			shouldRetry:   func(err error) bool { return false },
+			expectedErr:   errStore,
+			expectedCalls: 1,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			flaky := &flakyLayer{n: 2}
+			chain, err := Layered[Store](&mapStore{values: map[string]string{"a": "apple"}}, flaky)
+			if err != nil {
+				t.Fatalf("failed to layer cake: %+v", err)
+			}
+
+			svc, err := WithRetry(chain, testCase.attempts, testCase.shouldRetry)
+			if err != nil {
+				t.Fatalf("failed to create proxy: %+v", err)
+			}
+
+			_, err = svc.Get("a")
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+
+			if flaky.calls != testCase.expectedCalls {
+				t.Fatalf("expected %d calls, got %d", testCase.expectedCalls, flaky.calls)
+			}
+		})
+	}
+}
+
+func Test_LazyBase(t *testing.T) {
+	var calls int
+	base := LazyBase(func() Service {
+		calls++
+		return &LayerA{}
+	})
+
+	svc, err := Layered[Service](base, &LayerB{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if calls != 0 {
+		t.Fatalf("expected the base not to be constructed before a call, got %d calls", calls)
+	}
+
+	svc.Fruits()
+	svc.Veggies()
+
+	if calls != 1 {
+		t.Fatalf("expected the base to be constructed once, got %d calls", calls)
+	}
+}
+
+func Test_Intercept(t *testing.T) {
+	chain, err := Layered[Store](&mapStore{}, &partialLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err := Intercept(chain, func(method string, args []reflect.Value) []reflect.Value {
+		for i, arg := range args {
+			if arg.Kind() == reflect.String {
+				args[i] = reflect.ValueOf(strings.ToUpper(arg.String()))
+			}
+		}
+		return args
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if err := svc.Put("a", "apple"); err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+
+	if v, err := chain.Get("A"); err != nil || v != "APPLE" {
+		t.Fatalf("expected APPLE to be stored under A, got %q (%v)", v, err)
+	}
+
+	if v, err := svc.Get("a"); err != nil || v != "APPLE" {
+		t.Fatalf("expected APPLE, got %q (%v)", v, err)
+	}
+}
+
+func Test_Intercept_InvalidArgs(t *testing.T) {
+	svc, err := Intercept[Store](&mapStore{}, func(method string, args []reflect.Value) []reflect.Value {
+		return []reflect.Value{reflect.ValueOf(42)}
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "argument 0 of method Get must be string") {
+			t.Fatalf("expected a descriptive panic, got %q", msg)
+		}
+	}()
+
+	_, _ = svc.Get("a")
+}
+
+func Test_Route(t *testing.T) {
+	fruits, err := Layered[Service](&LayerA{}, &LayerB{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	veggies, err := Layered[Service](&LayerA{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err := Route(map[string]Service{"Fruits": fruits}, veggies)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected [Apple Banana], got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Artichoke Dill Cilantro]" {
+		t.Fatalf("expected [Artichoke Dill Cilantro], got %v", svc.Veggies())
+	}
+}
+
+func Test_Route_Errors(t *testing.T) {
+	testTable := map[string]struct {
+		routes      map[string]Service
+		fallback    Service
+		expectedErr string
+	}{
+		"Unknown method": {
+			routes:      map[string]Service{"Herbs": &LayerA{}},
+			fallback:    &LayerA{},
+			expectedErr: "has no method Herbs",
+		},
+		"Nil route": {
+			routes:      map[string]Service{"Fruits": nil},
+			fallback:    &LayerA{},
+			expectedErr: "route Fruits: chain of type cake.Service must not be nil",
+		},
+		"Nil fallback": {
+			routes:      map[string]Service{},
+			fallback:    nil,
+			expectedErr: "fallback: chain of type cake.Service must not be nil",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			_, err := Route(testCase.routes, testCase.fallback)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_Halt(t *testing.T) {
+	flaky := &flakyLayer{}
+	chain, err := Layered[Store](&mapStore{values: map[string]string{"a": "apple"}}, flaky)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	errBlocked := errors.New("blocked")
+	svc, err := Intercept(chain, func(method string, args []reflect.Value) []reflect.Value {
+		if method == "Get" && args[0].String() == "blocked" {
+			Halt("", errBlocked)
+		} else if method == "Len" {
+			Halt(42)
+		}
+		return args
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if _, err := svc.Get("blocked"); !errors.Is(err, errBlocked) {
+		t.Fatalf("expected %v, got %v", errBlocked, err)
+	}
+
+	if flaky.calls != 0 {
+		t.Fatalf("expected the chain not to be called, got %d calls", flaky.calls)
+	}
+
+	if n := svc.Len(); n != 42 {
+		t.Fatalf("expected 42, got %d", n)
+	}
+
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected apple, got %q (%v)", v, err)
+	}
+}
+
+// panickingStore panics on every call.
+type panickingStore struct{ Store }
+
+func (s *panickingStore) Get(key string) (string, error) { panic("get " + key) }
+func (s *panickingStore) Len() int                       { panic("len") }
+
+func Test_WithPanicBoundary(t *testing.T) {
+	chain, err := Layered[Store](&panickingStore{}, &partialLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	var recovered []any
+	svc, err := WithPanicBoundary(chain, func(r any) error {
+		recovered = append(recovered, r)
+		return fmt.Errorf("recovered: %v", r)
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	v, err := svc.Get("a")
+	if err == nil || err.Error() != "recovered: get a" {
+		t.Fatalf("expected the recovered error, got %v", err)
+	}
+	if v != "" {
+		t.Fatalf("expected a zero result, got %q", v)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "len" {
+				t.Fatalf("expected Len to re-panic, got %v", r)
+			}
+		}()
+		svc.Len()
+	}()
+
+	if fmt.Sprint(recovered) != "[get a len]" {
+		t.Fatalf("expected onPanic to see both panics, got %v", recovered)
+	}
+}
+
+func Test_Toggleable(t *testing.T) {
+	layer, enabled := Toggleable[Service](&LayerD{})
+
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, layer, &LayerC{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple Durian Banana]" {
+		t.Fatalf("expected [Apple Durian Banana], got %v", svc.Fruits())
+	}
+
+	enabled.Store(false)
+	if fmt.Sprint(svc.Fruits()) != "[Apple Banana]" {
+		t.Fatalf("expected the disabled layer to be skipped, got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Artichoke Cilantro Basil]" {
+		t.Fatalf("expected the disabled layer to be skipped, got %v", svc.Veggies())
+	}
+
+	enabled.Store(true)
+	if fmt.Sprint(svc.Veggies()) != "[Artichoke Cilantro Dill Basil]" {
+		t.Fatalf("expected the layer to be enabled again, got %v", svc.Veggies())
+	}
+
+	if Describe(svc) != "LayerB -> LayerD -> LayerC -> LayerA" {
+		t.Fatalf("expected the proxy to be described as its layer, got %s", Describe(svc))
+	}
+}
+
+func Test_Toggleable_Collected(t *testing.T) {
+	var addr = func() uintptr {
+		layer, _ := Toggleable[Service](&LayerD{})
+		return reflect.ValueOf(layer).Pointer()
+	}()
+
+	var ok = true
+	for i := 0; i < 100 && ok; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		_, ok = standIns.Load(addr)
+	}
+
+	if ok {
+		t.Fatalf("expected the stand-in to be forgotten once its proxy is collected")
+	}
+}
+
+func Test_Toggleable_Hooks(t *testing.T) {
+	var log []string
+	layer, _ := Toggleable[Service](&closingLayer{name: "toggled", log: &log})
+	aware, _ := Toggleable[Service](&depthLayer{})
+
+	_, cleanup, err := LayeredWithCleanup[Service](&LayerA{}, &LayerB{}, aware, layer)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if depth := unwrapStandIn(aware).(*depthLayer).depth; depth != 1 {
+		t.Fatalf("expected the toggled layer to be told its depth 1, got %d", depth)
+	}
+
+	if err := cleanup(); err != nil {
+		t.Fatalf("failed to clean up: %+v", err)
+	}
+
+	if fmt.Sprint(log) != "[close toggled]" {
+		t.Fatalf("expected the toggled layer to be closed, got %v", log)
+	}
+}
+
+// Doer is a context-aware interface used to exercise the proxies that care about contexts.
+type Doer interface {
+	Do(ctx context.Context) error
+}
+
+type doerProxy struct {
+	DoFunc func(ctx context.Context) error
+}
+
+func (p *doerProxy) Do(ctx context.Context) error { return p.DoFunc(ctx) }
+
+// waitingDoer waits for its context to be done.
+type waitingDoer struct{ calls int }
+
+func (d *waitingDoer) Do(ctx context.Context) error {
+	d.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_WithTimeout(t *testing.T) {
+	base := &waitingDoer{}
+	svc, err := WithTimeout[Doer](base, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if err := svc.Do(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	svc, err = WithTimeout[Doer](base, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svc.Do(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the caller's cancellation to be honored, got %v", err)
+	}
+}
+
+func Test_WithRetry_Context(t *testing.T) {
+	base := &waitingDoer{}
+	svc, err := WithRetry[Doer](base, 3, func(error) bool { return true })
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svc.Do(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if base.calls != 1 {
+		t.Fatalf("expected no retries once the context is done, got %d calls", base.calls)
+	}
+}
+
+func Test_DisableMethod(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err := DisableMethod(chain, "Fruits")
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if fmt.Sprint(svc.Fruits()) != "[Apple]" {
+		t.Fatalf("expected only the base to be called, got %v", svc.Fruits())
+	}
+
+	if fmt.Sprint(svc.Veggies()) != "[Artichoke Dill Cilantro Basil]" {
+		t.Fatalf("expected the whole chain to be called, got %v", svc.Veggies())
+	}
+
+	if _, err := DisableMethod(chain, "Herbs"); err == nil {
+		t.Fatalf("expected an error for an unknown method")
+	}
+}
+
+type greeterProxy struct {
+	GreetFunc    func() string
+	WithNameFunc func(name string) Greeter
+}
+
+func (p *greeterProxy) Greet() string                { return p.GreetFunc() }
+func (p *greeterProxy) WithName(name string) Greeter { return p.WithNameFunc(name) }
+
+func Test_SelfWrapping(t *testing.T) {
+	chain, err := Layered[Greeter](&greeterBase{name: "world"}, &exclaimLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err := SelfWrapping(chain)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if greeting := svc.Greet(); greeting != "Hello, world!" {
+		t.Fatalf("expected Hello, world!, got %q", greeting)
+	}
+
+	if greeting := svc.WithName("cake").Greet(); greeting != "Hello, cake!" {
+		t.Fatalf("expected the layers to survive a fluent call, got %q", greeting)
+	}
+
+	if greeting := svc.WithName("cake").WithName("again").Greet(); greeting != "Hello, again!" {
+		t.Fatalf("expected the layers to survive repeated fluent calls, got %q", greeting)
+	}
+}
@@ -0,0 +1,57 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrReentrancyLimit is returned by the proxy of WithReentrancyGuard when calls re-enter it more
+// than the allowed number of times.
+var ErrReentrancyLimit = errors.New("reentrancy limit exceeded")
+
+// WithReentrancyGuard returns a proxy around chain that tracks how many calls made through it are in
+// progress on each goroutine. A call that would exceed maxDepth, e.g. because a layer calls back
+// into the top of the chain without a way out, is not made: for methods that return an error as
+// their last value the proxy returns ErrReentrancyLimit, other methods panic. Only calls that go
+// through the proxy are counted, so layers must re-enter the chain through the proxy itself.
+func WithReentrancyGuard[T interface{}](chain T, maxDepth int) (T, error) {
+	target, err := chainValue(chain)
+	if err != nil {
+		return *new(T), err
+	} else if maxDepth < 1 {
+		return *new(T), fmt.Errorf("maxDepth must be at least 1, got %d", maxDepth)
+	}
+
+	var mu sync.Mutex
+	var depths = make(map[uint64]int)
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		id := goid()
+
+		mu.Lock()
+		depth := depths[id] + 1
+		if depth <= maxDepth {
+			depths[id] = depth
+		}
+		mu.Unlock()
+
+		if depth > maxDepth {
+			err := fmt.Errorf("%w: %s re-entered %d times", ErrReentrancyLimit, method.Name, maxDepth)
+			if !returnsError(method.Type) {
+				panic(fmt.Sprintf("cake: WithReentrancyGuard: %v", err))
+			}
+			return errorResults(method, err)
+		}
+
+		defer func() {
+			mu.Lock()
+			if depths[id]--; depths[id] == 0 {
+				delete(depths, id)
+			}
+			mu.Unlock()
+		}()
+		return call(target, method, args)
+	})
+}
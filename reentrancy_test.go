@@ -0,0 +1,62 @@
+package cake
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// reentrantLayer calls back into the top of the chain on every call.
+type reentrantLayer struct {
+	Store
+	top   Store
+	calls int
+}
+
+func (l *reentrantLayer) Get(key string) (string, error) {
+	l.calls++
+	return l.top.Get(key)
+}
+
+func (l *reentrantLayer) Len() int {
+	l.calls++
+	return l.top.Len()
+}
+
+func Test_WithReentrancyGuard(t *testing.T) {
+	layer := &reentrantLayer{}
+	chain, err := Layered[Store](&mapStore{}, layer)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	svc, err := WithReentrancyGuard(chain, 3)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+	layer.top = svc
+
+	if _, err := svc.Get("a"); !errors.Is(err, ErrReentrancyLimit) {
+		t.Fatalf("expected %v, got %v", ErrReentrancyLimit, err)
+	}
+
+	if layer.calls != 3 {
+		t.Fatalf("expected the layer to be entered 3 times, got %d", layer.calls)
+	}
+
+	func() {
+		defer func() {
+			msg, _ := recover().(string)
+			if !strings.Contains(msg, "reentrancy limit exceeded: Len re-entered 3 times") {
+				t.Fatalf("expected a descriptive panic, got %q", msg)
+			}
+		}()
+		svc.Len()
+	}()
+
+	// the depth is released as the calls unwind
+	layer.top = &mapStore{values: map[string]string{"a": "apple"}}
+	if v, err := svc.Get("a"); err != nil || v != "apple" {
+		t.Fatalf("expected apple, got %q (%v)", v, err)
+	}
+}
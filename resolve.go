@@ -0,0 +1,250 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrNotStructPointer is returned when a layer is not a pointer to a struct.
+var ErrNotStructPointer = errors.New("layer must be a non-nil pointer to a struct")
+
+// ErrFieldTypeMismatch is returned when the field resolved to hold the next layer cannot hold it,
+// e.g. a field named Service of type string.
+var ErrFieldTypeMismatch = errors.New("field type mismatch")
+
+// defaultFieldResolver resolves the field of layerType that holds the next layer. In order of
+// precedence, it is:
+//
+//  1. the field tagged with `cake:"delegate"`
+//  2. the only exported field of an interface type that T can be assigned to
+//  3. the field named after the interface type, usually the embedded interface
+//
+// Anonymous interface types have no name, so they are only resolved by tag or by type.
+func defaultFieldResolver(layerType reflect.Type, interfaceType reflect.Type) ([]int, error) {
+	if index, err := fieldByTag(layerType); err != nil || index != nil {
+		return index, err
+	}
+
+	index, err := fieldByType(layerType, interfaceType)
+	if err == nil {
+		return index, nil
+	}
+
+	name := interfaceName(interfaceType)
+	if name == "" {
+		return nil, err
+	}
+
+	field, ok := layerType.FieldByName(name)
+	if !ok {
+		return nil, fmt.Errorf("field %s not found", name)
+	}
+	return field.Index, nil
+}
+
+// interfaceName returns the name of interfaceType as the name of a field embedding it. For an
+// instantiated generic interface such as Repository[User] this drops the type arguments, which
+// reflect includes in the name.
+func interfaceName(interfaceType reflect.Type) string {
+	name := interfaceType.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// fieldByTag resolves the only field of layerType tagged with `cake:"delegate"`. It returns a nil
+// index if there is no such field.
+func fieldByTag(layerType reflect.Type) ([]int, error) {
+	var index []int
+	for i := 0; i < layerType.NumField(); i++ {
+		if layerType.Field(i).Tag.Get("cake") != "delegate" {
+			continue
+		} else if index != nil {
+			return nil, fmt.Errorf("more than one field tagged `cake:\"delegate\"`")
+		}
+		index = layerType.Field(i).Index
+	}
+	return index, nil
+}
+
+// fieldByType resolves the only exported field of layerType whose type is a non-empty interface
+// that interfaceType can be assigned to.
+func fieldByType(layerType reflect.Type, interfaceType reflect.Type) ([]int, error) {
+	var index []int
+	for i := 0; i < layerType.NumField(); i++ {
+		field := layerType.Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Interface || field.Type.NumMethod() == 0 {
+			continue
+		} else if !interfaceType.AssignableTo(field.Type) {
+			continue
+		} else if index != nil {
+			return nil, fmt.Errorf("more than one field of type %s", interfaceType)
+		}
+		index = field.Index
+	}
+
+	if index == nil {
+		return nil, fmt.Errorf("no field of type %s", interfaceType)
+	}
+	return index, nil
+}
+
+// resolve returns the index sequence of the field in layerType that holds the next layer.
+func (o *options) resolve(layerType reflect.Type, interfaceType reflect.Type) ([]int, error) {
+	if o.cache == nil {
+		return o.resolveUncached(layerType, interfaceType)
+	}
+
+	var key = resolveKey{layerType: layerType, interfaceType: interfaceType}
+	if res, ok := o.cache.Load(key); ok {
+		return res.(resolved).index, res.(resolved).err
+	}
+
+	index, err := o.resolveUncached(layerType, interfaceType)
+	o.cache.Store(key, resolved{index: index, err: err})
+	return index, err
+}
+
+func (o *options) resolveUncached(layerType reflect.Type, interfaceType reflect.Type) ([]int, error) {
+	if o.resolver != nil {
+		return o.resolver(layerType, interfaceType)
+	}
+	return defaultFieldResolver(layerType, interfaceType)
+}
+
+// resolveKey is the key of a resolved delegate field in the cache of a Builder.
+type resolveKey struct {
+	layerType     reflect.Type
+	interfaceType reflect.Type
+}
+
+// resolved is a delegate field resolved by options.resolve.
+type resolved struct {
+	index []int
+	err   error
+}
+
+// standIns maps the address of each proxy that stands in for a layer, such as the ones returned by
+// Toggleable, to that layer, so that the delegate field and the optional interfaces of the layer
+// are used in place of the proxy's. The address is used as the key so that the map doesn't keep
+// the proxy alive, and the entry is removed once the proxy is garbage collected.
+var standIns sync.Map
+
+// registerStandIn records proxy as standing in for layer for as long as proxy is reachable.
+func registerStandIn(proxy any, layer any) {
+	addr := reflect.ValueOf(proxy).Pointer()
+	standIns.Store(addr, layer)
+	runtime.SetFinalizer(proxy, func(any) { standIns.Delete(addr) })
+}
+
+// unwrapStandIn returns the layer that layer stands in for, or layer itself if it is not a stand-in.
+func unwrapStandIn(layer any) any {
+	val := reflect.ValueOf(layer)
+	if !val.IsValid() || val.Kind() != reflect.Ptr || val.IsNil() {
+		return layer
+	}
+
+	if inner, ok := standIns.Load(val.Pointer()); ok {
+		return inner
+	}
+	return layer
+}
+
+// layerInfo describes a layer that can be wired.
+type layerInfo struct {
+	// value is the pointer to the layer struct.
+	value reflect.Value
+	// field is the settable field of the layer struct that holds the next layer.
+	field reflect.Value
+	// name is the name of field.
+	name string
+}
+
+// inspectLayer checks that layer can be wired into a cake of interfaceType and returns its
+// delegate field. It never panics, any problem with the layer is returned as an error.
+func (o *options) inspectLayer(layer any, interfaceType reflect.Type) (layerInfo, error) {
+	layer = unwrapStandIn(layer)
+
+	val := reflect.ValueOf(layer)
+	if !val.IsValid() || val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return layerInfo{}, fmt.Errorf("%w, got %T", ErrNotStructPointer, layer)
+	}
+
+	index, err := o.resolve(val.Elem().Type(), interfaceType)
+	if err != nil {
+		return layerInfo{}, err
+	}
+
+	field, name, err := fieldByIndex(val.Elem(), index)
+	if err != nil {
+		return layerInfo{}, err
+	} else if !field.CanSet() {
+		return layerInfo{}, fmt.Errorf("field %s cannot be set", name)
+	} else if !canHold(field.Type(), interfaceType) {
+		return layerInfo{}, fmt.Errorf("%w: field %s is of type %s, which cannot hold a %s", ErrFieldTypeMismatch, name, field.Type(), interfaceType)
+	}
+
+	return layerInfo{value: val, field: field, name: name}, nil
+}
+
+// canHold reports whether a field of fieldType can hold at least some values of interfaceType. A
+// field of a narrower interface, e.g. one written against an older version of the interface, can
+// hold the values that implement it, so whether a particular value fits is checked by set.
+func canHold(fieldType reflect.Type, interfaceType reflect.Type) bool {
+	if interfaceType.AssignableTo(fieldType) {
+		return true
+	} else if fieldType.Kind() == reflect.Interface {
+		return true
+	}
+	return fieldType.Implements(interfaceType)
+}
+
+// check returns ErrFieldTypeMismatch if the delegate field of the layer cannot hold next.
+func (info layerInfo) check(next reflect.Value) error {
+	if !next.Type().AssignableTo(info.field.Type()) {
+		return fmt.Errorf("%w: field %s is of type %s, which cannot hold a %s", ErrFieldTypeMismatch, info.name, info.field.Type(), next.Type())
+	}
+	return nil
+}
+
+// set sets the delegate field of the layer to next, or returns ErrFieldTypeMismatch if the field
+// cannot hold it.
+func (info layerInfo) set(next reflect.Value) error {
+	if err := info.check(next); err != nil {
+		return err
+	}
+	info.field.Set(next)
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndexErr, but returns an error instead of panicking
+// when index is out of range. It also returns the name of the field.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, string, error) {
+	if len(index) == 0 {
+		return reflect.Value{}, "", fmt.Errorf("empty field index")
+	}
+
+	var name string
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, "", fmt.Errorf("field %s is a nil pointer to an embedded struct", name)
+			}
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct || x < 0 || x >= v.NumField() {
+			return reflect.Value{}, "", fmt.Errorf("field index %v is out of range for %s", index, v.Type())
+		}
+
+		name = v.Type().Field(x).Name
+		v = v.Field(x)
+	}
+
+	return v, name, nil
+}
@@ -0,0 +1,247 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type intLayer int
+
+func (intLayer) Fruits() []string  { return nil }
+func (intLayer) Veggies() []string { return nil }
+
+type unexportedLayer struct{ service Service }
+
+type pointerEmbedLayer struct{ *LayerB }
+
+// stringFieldLayer has a field named after the interface that is not of the interface type.
+type stringFieldLayer struct{ Service string }
+
+func (*stringFieldLayer) Fruits() []string  { return nil }
+func (*stringFieldLayer) Veggies() []string { return nil }
+
+func Test_inspectLayer(t *testing.T) {
+	var interfaceType = TypeOf[Service]()
+	var one = intLayer(1)
+
+	testTable := map[string]struct {
+		layer       any
+		resolver    FieldResolver
+		expectedErr string
+	}{
+		"Untyped nil": {
+			layer:       nil,
+			expectedErr: ErrNotStructPointer.Error(),
+		},
+		"Nil pointer": {
+			layer:       (*LayerB)(nil),
+			expectedErr: ErrNotStructPointer.Error(),
+		},
+		"Non-pointer value": {
+			layer:       LayerB{},
+			expectedErr: ErrNotStructPointer.Error(),
+		},
+		"Pointer to a non-struct": {
+			layer:       &one,
+			expectedErr: ErrNotStructPointer.Error(),
+		},
+		"Missing interface field": {
+			layer:       &brokenLayer{},
+			expectedErr: "field Service not found",
+		},
+		"Unexported field": {
+			layer: &unexportedLayer{},
+			resolver: func(reflect.Type, reflect.Type) ([]int, error) {
+				return []int{0}, nil
+			},
+			expectedErr: "field service cannot be set",
+		},
+		"Out of range index from a resolver": {
+			layer: &LayerB{},
+			resolver: func(reflect.Type, reflect.Type) ([]int, error) {
+				return []int{3}, nil
+			},
+			expectedErr: "field index [3] is out of range",
+		},
+		"Nil embedded struct pointer": {
+			layer:       &pointerEmbedLayer{},
+			expectedErr: "field LayerB is a nil pointer",
+		},
+		"Field of the wrong type": {
+			layer:       &stringFieldLayer{},
+			expectedErr: "field Service is of type string",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			o := newOptions([]Option{WithFieldResolver(testCase.resolver)})
+			_, err := o.inspectLayer(testCase.layer, interfaceType)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_inspectLayer_Valid(t *testing.T) {
+	layer := &LayerB{}
+	info, err := newOptions(nil).inspectLayer(layer, TypeOf[Service]())
+	if err != nil {
+		t.Fatalf("failed to inspect layer: %+v", err)
+	}
+
+	if info.name != "Service" {
+		t.Fatalf("expected field Service, got %s", info.name)
+	}
+
+	info.field.Set(reflect.ValueOf(&LayerA{}))
+	if _, ok := layer.Service.(*LayerA); !ok {
+		t.Fatalf("expected the field to reference the layer")
+	}
+}
+
+func Test_Layered_PointerToNonStruct(t *testing.T) {
+	var one = intLayer(1)
+
+	_, err := Layered[Service](&LayerA{}, &one)
+	if !errors.Is(err, ErrNotStructPointer) {
+		t.Fatalf("expected %v, got %v", ErrNotStructPointer, err)
+	}
+}
+
+type taggedLayer struct {
+	Next Service `cake:"delegate"`
+}
+
+func (l *taggedLayer) Fruits() []string  { return append(l.Next.Fruits(), "Tagged") }
+func (l *taggedLayer) Veggies() []string { return l.Next.Veggies() }
+
+type taggedEmbeddingLayer struct {
+	Service
+	Next Service `cake:"delegate"`
+}
+
+func (l *taggedEmbeddingLayer) Fruits() []string { return append(l.Next.Fruits(), "Tagged") }
+
+type typedLayer struct {
+	Inner Service
+}
+
+func (l *typedLayer) Fruits() []string  { return append(l.Inner.Fruits(), "Typed") }
+func (l *typedLayer) Veggies() []string { return l.Inner.Veggies() }
+
+type ambiguousTypeLayer struct {
+	Service
+	Fallback Service
+}
+
+type doubleTaggedLayer struct {
+	Service `cake:"delegate"`
+	Next    Service `cake:"delegate"`
+}
+
+func Test_defaultFieldResolver(t *testing.T) {
+	testTable := map[string]struct {
+		layer         any
+		expectedField string
+		expectedErr   string
+	}{
+		"Resolves a tagged field": {
+			layer:         &taggedLayer{},
+			expectedField: "Next",
+		},
+		"Prefers the tagged field over the embedded interface": {
+			layer:         &taggedEmbeddingLayer{},
+			expectedField: "Next",
+		},
+		"Resolves the only field of the interface type": {
+			layer:         &typedLayer{},
+			expectedField: "Inner",
+		},
+		"Resolves the embedded interface by name when the type is ambiguous": {
+			layer:         &ambiguousTypeLayer{},
+			expectedField: "Service",
+		},
+		"Resolves the embedded interface": {
+			layer:         &LayerB{},
+			expectedField: "Service",
+		},
+		"Errors on more than one tagged field": {
+			layer:       &doubleTaggedLayer{},
+			expectedErr: "more than one field tagged",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			layerType := reflect.TypeOf(testCase.layer).Elem()
+			index, err := defaultFieldResolver(layerType, TypeOf[Service]())
+			if testCase.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("failed to resolve field: %+v", err)
+			}
+
+			if field := layerType.FieldByIndex(index); field.Name != testCase.expectedField {
+				t.Fatalf("expected field %s, got %s", testCase.expectedField, field.Name)
+			}
+		})
+	}
+}
+
+func Test_Layered_TaggedField(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &taggedEmbeddingLayer{}, &typedLayer{}, &taggedLayer{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Tagged", "Typed", "Tagged"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+}
+
+// Svc is an alias of Service, so a layer embedding it has a field named Svc.
+type Svc = Service
+
+type aliasLayer struct{ Svc }
+
+func (l *aliasLayer) Fruits() []string {
+	return append(l.Svc.Fruits(), "Aliased")
+}
+
+func Test_Layered_TypeAlias(t *testing.T) {
+	layer := &aliasLayer{}
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, layer)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expectedFruits := []string{"Apple", "Aliased", "Banana"}
+	if fmt.Sprint(svc.Fruits()) != fmt.Sprint(expectedFruits) {
+		t.Fatalf("expectedFruits %v, got %v", expectedFruits, svc.Fruits())
+	}
+
+	if info, err := newOptions(nil).inspectLayer(layer, TypeOf[Service]()); err != nil || info.name != "Svc" {
+		t.Fatalf("expected the field Svc to be resolved by its type, got %q (%v)", info.name, err)
+	}
+}
+
+func Test_Layered_FieldTypeMismatch(t *testing.T) {
+	_, err := Layered[Service](&LayerA{}, &LayerB{}, &stringFieldLayer{})
+
+	var layerErr *LayerError
+	if !errors.Is(err, ErrFieldTypeMismatch) || !errors.As(err, &layerErr) || layerErr.Index != 1 {
+		t.Fatalf("expected %v for layer 1, got %v", ErrFieldTypeMismatch, err)
+	}
+
+	expectedErr := "layer '*cake.stringFieldLayer': field type mismatch: field Service is of type string, which cannot hold a cake.Service"
+	if err.Error() != expectedErr {
+		t.Fatalf("expected %q, got %q", expectedErr, err)
+	}
+}
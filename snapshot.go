@@ -0,0 +1,82 @@
+package cake
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ChainSnapshot is an immutable record of the structure of a layered cake at the time it was
+// taken. It holds the concrete types and names of the layers and the base, and optionally a copy
+// of the metadata of a Chain, but no references to the layers themselves. A ChainSnapshot can
+// therefore be shared and inspected from any goroutine while the chain is being changed by helpers
+// such as RemoveMatching or Swap.
+type ChainSnapshot struct {
+	layers []reflect.Type
+	names  []string
+	base   reflect.Type
+	meta   map[string]any
+}
+
+// Snapshot returns a ChainSnapshot of chain. Snapshot itself walks the live chain, so it must not
+// be called concurrently with changes to it.
+func Snapshot[T interface{}](chain T) ChainSnapshot {
+	return snapshot(chain, layerName)
+}
+
+// snapshot is like Snapshot, but names each value with name.
+func snapshot[T interface{}](chain T, name func(layer any) string) ChainSnapshot {
+	var snapshot ChainSnapshot
+	WalkLayers(chain, func(layer T, _ int) bool {
+		snapshot.layers = append(snapshot.layers, reflect.TypeOf(layer))
+		snapshot.names = append(snapshot.names, name(layer))
+		return true
+	})
+
+	if n := len(snapshot.layers); n != 0 {
+		snapshot.base = snapshot.layers[n-1]
+		snapshot.layers = snapshot.layers[:n-1]
+	}
+	return snapshot
+}
+
+// Snapshot returns a ChainSnapshot of the cake held by c, including a copy of its metadata and the
+// names of its layers if it was built with LayeredNamed. The metadata values themselves are not
+// copied.
+func (c *Chain[T]) Snapshot() ChainSnapshot {
+	var snapshot = snapshot(c.value, func(layer any) string { return lookupName(c.names, layer) })
+	snapshot.meta = c.Meta()
+	return snapshot
+}
+
+// Layers returns the concrete types of the layers, from the outermost inward.
+func (s ChainSnapshot) Layers() []reflect.Type {
+	return append([]reflect.Type(nil), s.layers...)
+}
+
+// Base returns the concrete type of the base, or nil if the chain was empty.
+func (s ChainSnapshot) Base() reflect.Type {
+	return s.base
+}
+
+// Len returns the number of layers, not including the base.
+func (s ChainSnapshot) Len() int {
+	return len(s.layers)
+}
+
+// Meta returns a copy of the metadata captured with the snapshot, or nil if there is none.
+func (s ChainSnapshot) Meta() map[string]any {
+	if s.meta == nil {
+		return nil
+	}
+
+	var meta = make(map[string]any, len(s.meta))
+	for k, v := range s.meta {
+		meta[k] = v
+	}
+	return meta
+}
+
+// String describes the snapshot in the same form as Describe.
+func (s ChainSnapshot) String() string {
+	return strings.Join(s.names, " -> ")
+}
@@ -0,0 +1,64 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func Test_Snapshot(t *testing.T) {
+	chain, err := LayeredMeta[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+	chain.WithMeta("version", 1)
+
+	snapshot := chain.Snapshot()
+
+	// mutating the returned slices and maps must not change the snapshot.
+	snapshot.Layers()[0] = nil
+	snapshot.Meta()["version"] = 2
+
+	svc, err := RemoveMatching(chain.Value(), func(layer Service) bool {
+		_, ok := layer.(*LayerC)
+		return ok
+	})
+	if err != nil {
+		t.Fatalf("failed to remove layer: %+v", err)
+	}
+	chain.WithMeta("version", 3)
+
+	if Describe(svc) != "LayerB -> LayerD -> LayerA" {
+		t.Fatalf("expected the chain to be changed, got %s", Describe(svc))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if snapshot.String() != "LayerB -> LayerC -> LayerD -> LayerA" {
+				t.Errorf("expected the snapshot to be unchanged, got %s", snapshot)
+			}
+		}()
+	}
+	wg.Wait()
+
+	expectedLayers := []reflect.Type{reflect.TypeOf(&LayerB{}), reflect.TypeOf(&LayerC{}), reflect.TypeOf(&LayerD{})}
+	if fmt.Sprint(snapshot.Layers()) != fmt.Sprint(expectedLayers) || snapshot.Len() != 3 {
+		t.Fatalf("expected layers %v, got %v", expectedLayers, snapshot.Layers())
+	}
+
+	if snapshot.Base() != reflect.TypeOf(&LayerA{}) {
+		t.Fatalf("expected base *cake.LayerA, got %v", snapshot.Base())
+	}
+
+	if snapshot.Meta()["version"] != 1 {
+		t.Fatalf("expected version 1, got %v", snapshot.Meta()["version"])
+	}
+
+	if Snapshot[Service](nil).Base() != nil || Snapshot[Service](&LayerA{}).Meta() != nil {
+		t.Fatalf("expected an empty snapshot without metadata")
+	}
+}
@@ -0,0 +1,154 @@
+package cake
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+)
+
+// ChainSpec describes the structure of a layered cake: the concrete types of its layers, from the
+// outermost inward, and the concrete type of its base. It holds no references to the layers
+// themselves. Its JSON form identifies each type by its package path and name so that it can be
+// consumed by code generators, and read back into a ChainSpec by a program that has registered the
+// types with RegisterSpecType.
+type ChainSpec struct {
+	Layers []reflect.Type
+	Base   reflect.Type
+}
+
+// SpecOf returns the ChainSpec describing chain.
+func SpecOf[T interface{}](chain T) ChainSpec {
+	var spec ChainSpec
+	WalkLayers(chain, func(layer T, _ int) bool {
+		spec.Layers = append(spec.Layers, reflect.TypeOf(layer))
+		return true
+	})
+
+	if len(spec.Layers) != 0 {
+		spec.Base = spec.Layers[len(spec.Layers)-1]
+		spec.Layers = spec.Layers[:len(spec.Layers)-1]
+	}
+	return spec
+}
+
+type chainSpecJSON struct {
+	Layers []string `json:"layers"`
+	Base   string   `json:"base"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s ChainSpec) MarshalJSON() ([]byte, error) {
+	var out = chainSpecJSON{
+		Layers: make([]string, 0, len(s.Layers)),
+		Base:   qualifiedName(s.Base),
+	}
+	for _, layer := range s.Layers {
+		out.Layers = append(out.Layers, qualifiedName(layer))
+	}
+	return json.Marshal(out)
+}
+
+// specTypes maps the qualified name of each type registered with RegisterSpecType to the type.
+var specTypes sync.Map
+
+// RegisterSpecType registers the type L, e.g. *LayerB, so that ChainSpecs naming it can be
+// unmarshaled. Go cannot look types up by name at runtime, so every layer and base type of a spec
+// must be registered before it is unmarshaled.
+func RegisterSpecType[L interface{}]() {
+	t := TypeOf[L]()
+	specTypes.Store(qualifiedName(t), t)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It returns an error if a type of the spec has not
+// been registered with RegisterSpecType.
+func (s *ChainSpec) UnmarshalJSON(data []byte) error {
+	var in chainSpecJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	var spec = ChainSpec{Layers: make([]reflect.Type, 0, len(in.Layers))}
+	for _, name := range in.Layers {
+		t, err := specType(name)
+		if err != nil {
+			return err
+		}
+		spec.Layers = append(spec.Layers, t)
+	}
+
+	if in.Base != "" {
+		t, err := specType(in.Base)
+		if err != nil {
+			return err
+		}
+		spec.Base = t
+	}
+
+	*s = spec
+	return nil
+}
+
+// specType returns the type registered under the qualified name.
+func specType(name string) (reflect.Type, error) {
+	t, ok := specTypes.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s, it must be registered with RegisterSpecType", name)
+	}
+	return t.(reflect.Type), nil
+}
+
+// qualifiedName returns the name of t qualified by its full package path, e.g.
+// "*github.com/tylermmorton/cake.LayerA".
+func qualifiedName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+
+	var prefix string
+	for t.Kind() == reflect.Ptr {
+		prefix += "*"
+		t = t.Elem()
+	}
+
+	if t.PkgPath() == "" {
+		return prefix + t.String()
+	}
+	return prefix + t.PkgPath() + "." + t.Name()
+}
+
+// ChainEqual reports whether chains a and b have the same structure: the same concrete types of
+// layers in the same order, over a base of the same concrete type.
+func ChainEqual[T interface{}](a, b T) bool {
+	specA, specB := SpecOf(a), SpecOf(b)
+	if specA.Base != specB.Base || len(specA.Layers) != len(specB.Layers) {
+		return false
+	}
+
+	for i := range specA.Layers {
+		if specA.Layers[i] != specB.Layers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns a 64-bit FNV-1a hash of the qualified names of the layer types of s, in order,
+// followed by the qualified name of its base type. Specs of the same structure hash equal, across
+// processes and builds of the same program.
+func (s ChainSpec) Hash() uint64 {
+	h := fnv.New64a()
+	for _, layer := range s.Layers {
+		h.Write([]byte(qualifiedName(layer)))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(qualifiedName(s.Base)))
+	return h.Sum64()
+}
+
+// ChainHash returns a hash of the structure of chain, as described by SpecOf. This is useful as a
+// cache key for setup that depends only on how a cake is composed.
+func ChainHash[T interface{}](chain T) uint64 {
+	return SpecOf(chain).Hash()
+}
@@ -0,0 +1,104 @@
+package cake
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_SpecOf(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, If(false, &LayerC{}), &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	spec := SpecOf(svc)
+
+	expectedLayers := []reflect.Type{reflect.TypeOf(&LayerB{}), reflect.TypeOf(&LayerD{})}
+	if !reflect.DeepEqual(spec.Layers, expectedLayers) {
+		t.Fatalf("expected layers %v, got %v", expectedLayers, spec.Layers)
+	}
+
+	if spec.Base != reflect.TypeOf(&LayerA{}) {
+		t.Fatalf("expected base %v, got %v", reflect.TypeOf(&LayerA{}), spec.Base)
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("failed to marshal spec: %+v", err)
+	}
+
+	expectedJSON := `{"layers":["*github.com/tylermmorton/cake.LayerB","*github.com/tylermmorton/cake.LayerD"],"base":"*github.com/tylermmorton/cake.LayerA"}`
+	if string(data) != expectedJSON {
+		t.Fatalf("expected %s, got %s", expectedJSON, data)
+	}
+}
+
+func Test_ChainSpec_UnmarshalJSON(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	data, err := json.Marshal(SpecOf(svc))
+	if err != nil {
+		t.Fatalf("failed to marshal spec: %+v", err)
+	}
+
+	RegisterSpecType[*LayerA]()
+	RegisterSpecType[*LayerB]()
+	RegisterSpecType[*LayerD]()
+
+	var spec ChainSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("failed to unmarshal spec: %+v", err)
+	}
+
+	if !reflect.DeepEqual(spec, SpecOf(svc)) || spec.Hash() != ChainHash(svc) {
+		t.Fatalf("expected the spec to survive the round trip, got %+v", spec)
+	}
+
+	// LayerE is never registered
+	err = json.Unmarshal([]byte(`{"layers":["*github.com/tylermmorton/cake.LayerE"],"base":"*github.com/tylermmorton/cake.LayerA"}`), &spec)
+	if err == nil || err.Error() != "unknown type *github.com/tylermmorton/cake.LayerE, it must be registered with RegisterSpecType" {
+		t.Fatalf("expected an error for an unregistered type, got %v", err)
+	}
+}
+
+func Test_ChainEqual(t *testing.T) {
+	a, _ := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{})
+	b, _ := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{})
+	c, _ := Layered[Service](&LayerA{}, &LayerC{}, &LayerB{})
+	d, _ := Layered[Service](&mockService{}, &LayerB{}, &LayerC{})
+
+	if !ChainEqual(a, b) {
+		t.Fatalf("expected chains with the same structure to be equal")
+	}
+
+	if ChainEqual(a, c) {
+		t.Fatalf("expected chains with different orders not to be equal")
+	}
+
+	if ChainEqual(a, d) {
+		t.Fatalf("expected chains with different bases not to be equal")
+	}
+}
+
+func Test_ChainHash(t *testing.T) {
+	a, _ := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{})
+	b, _ := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{})
+	c, _ := Layered[Service](&LayerA{}, &LayerC{}, &LayerB{})
+	d, _ := Layered[Service](&mockService{}, &LayerB{}, &LayerC{})
+
+	if ChainHash(a) != ChainHash(b) {
+		t.Fatalf("expected chains with the same structure to hash equal")
+	}
+
+	if ChainHash(a) == ChainHash(c) {
+		t.Fatalf("expected chains with different orders to hash differently")
+	}
+
+	if ChainHash(a) == ChainHash(d) {
+		t.Fatalf("expected chains with different bases to hash differently")
+	}
+}
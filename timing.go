@@ -0,0 +1,111 @@
+package cake
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// WithLayerTiming measures how long each value in chain spends in each method call, and reports the
+// duration to sink along with the name of the value's type and of the method. It returns a proxy in
+// front of a shallow copy of chain, in which the delegate field of every layer points at a proxy in
+// front of its next layer, so that every hop between two values is timed. The layers of chain are
+// left untouched, and calls made on chain itself are not timed. The base is shared, not copied.
+//
+// The durations are exclusive: the time a value spends in a call, minus the time spent in the calls
+// it made to its next layer. The inclusive time of a value is therefore the sum of its own duration
+// and those of every value inward of it. Values that don't declare the method themselves are not
+// reported, their time is negligible and counts towards the next value that does. WithLayerTiming
+// requires a proxy registered for T with RegisterProxy.
+func WithLayerTiming[T interface{}](chain T, sink func(layerType, method string, d time.Duration)) (T, error) {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+
+	if _, err := chainValue(chain); err != nil {
+		return *new(T), err
+	}
+
+	layers, base, err := walkableLayers(chain)
+	if err != nil {
+		return *new(T), err
+	}
+
+	for i := range layers {
+		layers[i] = copyLayer(o, layers[i])
+	}
+	values := append(layers, base)
+
+	var timer = &hopTimer{frames: make(map[uint64][]time.Duration)}
+	var hops = make([]T, len(values))
+	for i, value := range values {
+		hop, err := timedHop(timer, value, sink)
+		if err != nil {
+			return *new(T), err
+		}
+		hops[i] = hop
+	}
+
+	for i, layer := range layers {
+		info, err := o.inspectLayer(layer, interfaceType)
+		if err != nil {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: err}
+		} else if err := info.set(reflect.ValueOf(hops[i+1])); err != nil {
+			return *new(T), &LayerError{Index: i, Layer: layer, Err: err}
+		}
+	}
+
+	return hops[0], nil
+}
+
+// hopTimer keeps, for each goroutine, a stack with the time spent in inner values by each call that
+// is in progress.
+type hopTimer struct {
+	mu     sync.Mutex
+	frames map[uint64][]time.Duration
+}
+
+// timedHop returns a proxy in front of value that times each call made on it.
+func timedHop[T interface{}](t *hopTimer, value T, sink func(layerType, method string, d time.Duration)) (T, error) {
+	target := reflect.ValueOf(value)
+	name := layerName(value)
+
+	return newProxy[T](func(method reflect.Method, args []reflect.Value) []reflect.Value {
+		id := goid()
+		t.push(id)
+		start := time.Now()
+		defer func() {
+			d := time.Since(start)
+			inner := t.pop(id, d)
+			if definesMethod(target.Type(), method.Name) {
+				sink(name, method.Name, d-inner)
+			}
+		}()
+		return call(target, method, args)
+	})
+}
+
+// push starts a new call on the goroutine id.
+func (t *hopTimer) push(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.frames[id] = append(t.frames[id], 0)
+}
+
+// pop ends the innermost call in progress on the goroutine id, which took d in total, and returns the
+// time it spent in inner values. d is added to the inner time of the call that made it.
+func (t *hopTimer) pop(id uint64, d time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	frames := t.frames[id]
+	inner := frames[len(frames)-1]
+	frames = frames[:len(frames)-1]
+	if len(frames) == 0 {
+		delete(t.frames, id)
+		return inner
+	}
+
+	frames[len(frames)-1] += d
+	t.frames[id] = frames
+	return inner
+}
@@ -0,0 +1,57 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type slowBase struct{ LayerA }
+
+func (b *slowBase) Fruits() []string {
+	time.Sleep(40 * time.Millisecond)
+	return b.LayerA.Fruits()
+}
+
+type slowLayer struct{ Service }
+
+func (l *slowLayer) Fruits() []string {
+	time.Sleep(10 * time.Millisecond)
+	return l.Service.Fruits()
+}
+
+func Test_WithLayerTiming(t *testing.T) {
+	chain, err := Layered[Service](&slowBase{}, &slowLayer{}, &LayerC{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	describedBefore, layersBefore := Describe(chain), Layers(chain)
+
+	var durations = make(map[string]time.Duration)
+	svc, err := WithLayerTiming(chain, func(layerType, method string, d time.Duration) {
+		durations[layerType+"."+method] += d
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %+v", err)
+	}
+
+	if Describe(chain) != describedBefore || fmt.Sprint(Layers(chain)) != fmt.Sprint(layersBefore) {
+		t.Fatalf("expected chain to be left untouched, got %s", Describe(chain))
+	}
+
+	svc.Fruits()
+
+	if len(durations) != 2 {
+		t.Fatalf("expected only the layers declaring Fruits to be reported, got %v", durations)
+	}
+
+	if d := durations["slowBase.Fruits"]; d < 40*time.Millisecond {
+		t.Fatalf("expected the base to take at least 40ms, got %s", d)
+	}
+
+	// the time spent in the base is excluded from the time of the layer
+	if d := durations["slowLayer.Fruits"]; d < 10*time.Millisecond || d >= 40*time.Millisecond {
+		t.Fatalf("expected the layer to take between 10ms and 40ms, got %s", d)
+	}
+}
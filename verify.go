@@ -0,0 +1,55 @@
+package cake
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Verify checks that every layer of chain is wired to a next layer. It returns an error naming
+// the type and depth of the layer where the chain is broken: a layer whose delegate holds a nil
+// pointer, or an innermost value whose delegate is nil while it doesn't declare every method of T
+// itself, meaning some calls would fall through to the nil delegate and panic. This is useful for
+// catching wiring mistakes after modifying a chain by hand. The nil delegate of a Terminal layer is
+// not an error. A chain wired through SetNext cannot be checked, and returns ErrNotWalkable.
+func Verify[T interface{}](chain T) error {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+
+	var last T
+	var depth = -1
+	WalkLayers(chain, func(layer T, d int) bool {
+		last, depth = layer, d
+		return true
+	})
+
+	if depth == -1 {
+		return fmt.Errorf("chain of type %s is nil", interfaceType)
+	} else if _, _, err := walkableLayers(chain); err != nil {
+		return err
+	}
+
+	info, err := o.inspectLayer(last, interfaceType)
+	if err != nil || info.field.Kind() != reflect.Interface {
+		// the innermost value is a plain base without a delegate
+		return nil
+	}
+
+	if !info.field.IsNil() {
+		return fmt.Errorf("layer '%T' at depth %d has a nil pointer in field %s", last, depth, info.name)
+	} else if isTerminal(last) {
+		return nil
+	}
+
+	var missing []string
+	for i := 0; i < interfaceType.NumMethod(); i++ {
+		if name := interfaceType.Method(i).Name; !definesMethod(reflect.TypeOf(last), name) {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("layer '%T' at depth %d has a nil field %s, calls to %s would panic", last, depth, info.name, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cake
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Verify(t *testing.T) {
+	testTable := map[string]struct {
+		build       func() Service
+		expectedErr string
+	}{
+		"Passes for a fully wired chain": {
+			build: func() Service {
+				svc, _ := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+				return svc
+			},
+		},
+		"Passes for a bare base": {
+			build: func() Service { return &mockService{} },
+		},
+		"Reports a layer wired to a nil pointer": {
+			build: func() Service {
+				c := &LayerC{}
+				svc, _ := Layered[Service](&LayerA{}, &LayerB{}, c, &LayerD{})
+				c.Service = (*LayerD)(nil)
+				return svc
+			},
+			expectedErr: "layer '*cake.LayerC' at depth 1 has a nil pointer in field Service",
+		},
+		"Reports a layer whose link was removed": {
+			build: func() Service {
+				c := &LayerC{}
+				svc, _ := Layered[Service](&LayerA{}, &LayerB{}, c, &LayerD{})
+				c.Service = nil
+				return svc
+			},
+			expectedErr: "layer '*cake.LayerC' at depth 1 has a nil field Service, calls to Fruits would panic",
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(testCase.build())
+			if testCase.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+// terminalLayer answers every call itself and never delegates.
+type terminalLayer struct{ Service }
+
+func (l *terminalLayer) Terminal()         {}
+func (l *terminalLayer) Fruits() []string  { return []string{"Tangerine"} }
+func (l *terminalLayer) Veggies() []string { return []string{"Turnip"} }
+
+// partialTerminalLayer never delegates, but only declares Fruits.
+type partialTerminalLayer struct{ Service }
+
+func (l *partialTerminalLayer) Terminal()        {}
+func (l *partialTerminalLayer) Fruits() []string { return []string{"Tangerine"} }
+
+func Test_Verify_Terminal(t *testing.T) {
+	for _, terminal := range []Service{&terminalLayer{}, &partialTerminalLayer{}} {
+		svc, err := Layered[Service](nil, &LayerB{}, terminal)
+		if err != nil {
+			t.Fatalf("failed to layer cake without a base: %+v", err)
+		}
+
+		if err := Verify(svc); err != nil {
+			t.Fatalf("expected the nil delegate of %T to be intentional, got %v", terminal, err)
+		}
+
+		if fruits := svc.Fruits(); len(fruits) != 2 || fruits[0] != "Tangerine" || fruits[1] != "Banana" {
+			t.Fatalf("expected [Tangerine Banana], got %v", fruits)
+		}
+	}
+}
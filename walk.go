@@ -0,0 +1,147 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotWalkable is returned by helpers that need to walk a chain when the chain has a layer wired
+// through SetNext, whose next layer cannot be followed.
+var ErrNotWalkable = errors.New("chain cannot be walked past a layer wired through SetNext")
+
+// WalkLayers calls visit for the outermost layer of chain and then for each next layer inward,
+// ending with the base. The depth passed to visit is 0 for the outermost layer and increases
+// inward. Returning false from visit stops the walk early. Layers are followed using the default
+// field resolution.
+func WalkLayers[T interface{}](chain T, visit func(layer T, depth int) bool) {
+	var o = newOptions(nil)
+	var interfaceType = TypeOf[T]()
+	var seen = make(map[uintptr]struct{})
+
+	if !reflect.ValueOf(chain).IsValid() {
+		return
+	}
+
+	var cur = chain
+	for depth := 0; ; depth++ {
+		val := reflect.ValueOf(cur)
+		if val.Kind() == reflect.Ptr {
+			// guard against cycles in chains that were wired by hand
+			if _, ok := seen[val.Pointer()]; ok {
+				return
+			}
+			seen[val.Pointer()] = struct{}{}
+		}
+
+		if !visit(cur, depth) {
+			return
+		}
+
+		next, ok := nextLayer[T](o, interfaceType, val)
+		if !ok {
+			return
+		}
+		cur = next
+	}
+}
+
+// nextLayer returns the value held by the delegate field of the layer val, or false if val is not
+// wired to a next layer.
+func nextLayer[T interface{}](o *options, interfaceType reflect.Type, val reflect.Value) (T, bool) {
+	info, err := o.inspectLayer(val.Interface(), interfaceType)
+	if err != nil || info.field.Kind() != reflect.Interface || info.field.IsNil() {
+		return *new(T), false
+	}
+	field := info.field
+
+	next, ok := field.Interface().(T)
+	if !ok {
+		return *new(T), false
+	}
+
+	if nextVal := reflect.ValueOf(next); nextVal.Kind() == reflect.Ptr && nextVal.IsNil() {
+		return *new(T), false
+	}
+
+	return next, true
+}
+
+// Layers returns the layers of chain from the outermost inward, not including the base.
+func Layers[T interface{}](chain T) []T {
+	layers, _ := layersAndBase(chain)
+	return layers
+}
+
+// layersAndBase returns the layers of chain from the outermost inward, and its base.
+func layersAndBase[T interface{}](chain T) ([]T, T) {
+	var values []T
+	WalkLayers(chain, func(layer T, _ int) bool {
+		values = append(values, layer)
+		return true
+	})
+
+	if len(values) == 0 {
+		return nil, *new(T)
+	} else if len(values) == 1 {
+		return nil, values[0]
+	}
+	return values[:len(values)-1], values[len(values)-1]
+}
+
+// walkableLayers is like layersAndBase, but returns ErrNotWalkable if the value it would report as
+// the base is a NextSetter whose next layer cannot be followed, meaning it is really a layer.
+func walkableLayers[T interface{}](chain T) ([]T, T, error) {
+	layers, base := layersAndBase(chain)
+	if _, ok := any(base).(NextSetter[T]); ok {
+		if _, err := newOptions(nil).inspectLayer(base, TypeOf[T]()); err != nil {
+			return nil, *new(T), fmt.Errorf("%w: layer '%T' at depth %d", ErrNotWalkable, base, len(layers))
+		}
+	}
+	return layers, base, nil
+}
+
+// Find returns the first value in chain, starting from the outermost layer and including the base,
+// that is of type L.
+func Find[L interface{}, T interface{}](chain T) (L, bool) {
+	var found L
+	var ok bool
+	WalkLayers(chain, func(layer T, _ int) bool {
+		found, ok = any(layer).(L)
+		return !ok
+	})
+	return found, ok
+}
+
+// Is reports whether any value in chain, including the base, is of type L.
+func Is[L interface{}, T interface{}](chain T) bool {
+	_, ok := Find[L](chain)
+	return ok
+}
+
+// As returns the first value in chain, starting with the outermost layer and ending with the base,
+// that satisfies U. This is useful for reaching a secondary interface such as io.Closer that might
+// only be implemented by the base.
+func As[T interface{}, U interface{}](chain T) (U, bool) {
+	return Find[U](chain)
+}
+
+// Base returns the base of chain, which is the innermost value of the chain.
+func Base[T interface{}](chain T) T {
+	_, base := layersAndBase(chain)
+	return base
+}
+
+// LayersImplementing returns every value in chain, from the outermost layer to the base, that
+// satisfies I. This is useful for cross-cutting operations such as flushing every layer that
+// buffers.
+func LayersImplementing[T interface{}, I interface{}](chain T) []I {
+	var matches []I
+	WalkLayers(chain, func(layer T, _ int) bool {
+		if match, ok := any(layer).(I); ok {
+			matches = append(matches, match)
+		}
+		return true
+	})
+	return matches
+}
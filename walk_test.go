@@ -0,0 +1,223 @@
+package cake
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_WalkLayers(t *testing.T) {
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	testTable := map[string]struct {
+		stopAt   int
+		expected []string
+	}{
+		"Visits every layer and the base": {
+			stopAt:   -1,
+			expected: []string{"*cake.LayerB", "*cake.LayerC", "*cake.LayerD", "*cake.LayerA"},
+		},
+		"Stops at the requested depth": {
+			stopAt:   1,
+			expected: []string{"*cake.LayerB", "*cake.LayerC"},
+		},
+		"Stops at the outermost layer": {
+			stopAt:   0,
+			expected: []string{"*cake.LayerB"},
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			var visited []string
+			WalkLayers(svc, func(layer Service, depth int) bool {
+				visited = append(visited, fmt.Sprintf("%T", layer))
+				return depth != testCase.stopAt
+			})
+
+			if fmt.Sprint(visited) != fmt.Sprint(testCase.expected) {
+				t.Fatalf("expected %v, got %v", testCase.expected, visited)
+			}
+		})
+	}
+}
+
+func Test_WalkLayers_Cycle(t *testing.T) {
+	b := &LayerB{}
+	c := &LayerC{Service: b}
+	b.Service = c
+
+	var visited []string
+	WalkLayers[Service](b, func(layer Service, depth int) bool {
+		visited = append(visited, fmt.Sprintf("%T", layer))
+		return true
+	})
+
+	expected := []string{"*cake.LayerB", "*cake.LayerC"}
+	if fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Fatalf("expected %v, got %v", expected, visited)
+	}
+}
+
+func Test_Layers_ExcludesBase(t *testing.T) {
+	b, d := &LayerB{}, &LayerD{}
+	svc, err := Layered[Service](&LayerA{}, b, If(false, &LayerC{}), d)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	layers := Layers(svc)
+	if len(layers) != 2 || layers[0] != b || layers[1] != d {
+		t.Fatalf("expected [%p %p], got %v", b, d, layers)
+	}
+
+	if _, ok := Base(svc).(*LayerA); !ok {
+		t.Fatalf("expected the base to be LayerA, got %T", Base(svc))
+	}
+
+	if layers := Layers[Service](&LayerA{}); len(layers) != 0 {
+		t.Fatalf("expected no layers for a bare base, got %v", layers)
+	}
+}
+
+func Test_Find(t *testing.T) {
+	d := &LayerD{}
+	svc, err := Layered[Service](&LayerA{}, &LayerB{}, d)
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if found, ok := Find[*LayerD](svc); !ok || found != d {
+		t.Fatalf("expected to find %p, got %p", d, found)
+	}
+
+	if !Is[*LayerA](svc) {
+		t.Fatalf("expected the base to be found")
+	}
+
+	if Is[*LayerC](svc) {
+		t.Fatalf("expected LayerC not to be found")
+	}
+}
+
+type closerLayer struct {
+	Service
+	closed bool
+}
+
+func (l *closerLayer) Close() error {
+	l.closed = true
+	return nil
+}
+
+func Test_As(t *testing.T) {
+	outer, base := &closerLayer{}, &closerLayer{}
+
+	testTable := map[string]struct {
+		build    func() (Service, error)
+		expected io.Closer
+	}{
+		"Returns the outermost layer when it satisfies U": {
+			build:    func() (Service, error) { return Layered[Service](&LayerA{}, outer, &LayerB{}) },
+			expected: outer,
+		},
+		"Returns the base when only the base satisfies U": {
+			build:    func() (Service, error) { return Layered[Service](base, &LayerB{}, &LayerD{}) },
+			expected: base,
+		},
+		"Returns false when nothing satisfies U": {
+			build:    func() (Service, error) { return Layered[Service](&LayerA{}, &LayerB{}, &LayerD{}) },
+			expected: nil,
+		},
+	}
+	for name, testCase := range testTable {
+		t.Run(name, func(t *testing.T) {
+			svc, err := testCase.build()
+			if err != nil {
+				t.Fatalf("failed to layer cake: %+v", err)
+			}
+
+			closer, ok := As[Service, io.Closer](svc)
+			if ok != (testCase.expected != nil) {
+				t.Fatalf("expected ok to be %t, got %t", testCase.expected != nil, ok)
+			}
+
+			if closer != testCase.expected {
+				t.Fatalf("expected %v, got %v", testCase.expected, closer)
+			}
+		})
+	}
+}
+
+func Test_LayersImplementing(t *testing.T) {
+	outer, inner := &closerLayer{}, &closerLayer{}
+	svc, err := Layered[Service](&LayerA{}, outer, &LayerB{}, inner, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	closers := LayersImplementing[Service, io.Closer](svc)
+	if len(closers) != 2 || closers[0] != outer || closers[1] != inner {
+		t.Fatalf("expected [%p %p], got %v", outer, inner, closers)
+	}
+}
+
+func Test_NotWalkable(t *testing.T) {
+	chain, err := Layered[Service](&LayerA{}, &setterLayer{fruit: "X"}, &setterLayer{fruit: "Y"})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	testTable := map[string]func() error{
+		"RemoveMatching": func() error {
+			_, err := RemoveMatching(chain, func(Service) bool { return false })
+			return err
+		},
+		"Swap": func() error {
+			_, err := Swap(chain, 0, 0)
+			return err
+		},
+		"RewrapResult": func() error {
+			_, err := RewrapResult[Service](chain, &LayerA{})
+			return err
+		},
+		"ReplaceByID": func() error {
+			_, err := ReplaceByID[Service](chain, "id", &LayerB{})
+			return err
+		},
+		"ShortCircuit": func() error {
+			_, err := ShortCircuit(chain)
+			return err
+		},
+		"DisableMethod": func() error {
+			_, err := DisableMethod(chain, "Fruits")
+			return err
+		},
+		"SelfWrapping": func() error {
+			_, err := SelfWrapping(chain)
+			return err
+		},
+		"WithLayerTiming": func() error {
+			_, err := WithLayerTiming(chain, func(string, string, time.Duration) {})
+			return err
+		},
+		"Verify": func() error {
+			return Verify(chain)
+		},
+	}
+	for name, fn := range testTable {
+		t.Run(name, func(t *testing.T) {
+			if err := fn(); !errors.Is(err, ErrNotWalkable) {
+				t.Fatalf("expected %v, got %v", ErrNotWalkable, err)
+			}
+		})
+	}
+
+	if fmt.Sprint(chain.Fruits()) != "[Apple Y X]" {
+		t.Fatalf("expected the chain to be left as it was, got %v", chain.Fruits())
+	}
+}
@@ -0,0 +1,47 @@
+package cake
+
+import (
+	"fmt"
+)
+
+// Wrapper builds a cake incrementally, starting from its base and wrapping one layer at a time. It
+// is created with From.
+type Wrapper[T interface{}] struct {
+	cur   T
+	count int
+	err   error
+}
+
+// From returns a Wrapper whose current value is base.
+func From[T interface{}](base T) *Wrapper[T] {
+	return &Wrapper[T]{cur: base}
+}
+
+// Then wires layer over the current value of w, which makes layer the new current value. Like in
+// Layered, a nil layer is skipped. Once wiring a layer fails, Then does nothing and Result returns
+// the error.
+func (w *Wrapper[T]) Then(layer T) *Wrapper[T] {
+	if w.err != nil {
+		return w
+	}
+
+	res, err := Layered[T](w.cur, layer)
+	if err != nil {
+		w.err = fmt.Errorf("then %d: %w", w.count, err)
+		return w
+	}
+
+	w.cur = res
+	w.count++
+	return w
+}
+
+// Result returns the current value of w, which is the outermost layer of the cake, or the first
+// error returned while wiring it. From(base).Then(a).Then(b).Result() is equivalent to
+// Layered(base, b, a).
+func (w *Wrapper[T]) Result() (T, error) {
+	if w.err != nil {
+		return *new(T), w.err
+	}
+	return w.cur, nil
+}
@@ -0,0 +1,31 @@
+package cake
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_From(t *testing.T) {
+	svc, err := From[Service](&LayerA{}).Then(&LayerD{}).Then(nil).Then(&LayerC{}).Then(&LayerB{}).Result()
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	expected, err := Layered[Service](&LayerA{}, &LayerB{}, &LayerC{}, &LayerD{})
+	if err != nil {
+		t.Fatalf("failed to layer cake: %+v", err)
+	}
+
+	if !ChainEqual(svc, expected) {
+		t.Fatalf("expected %s, got %s", Describe(expected), Describe(svc))
+	}
+
+	if fmt.Sprint(svc.Veggies()) != fmt.Sprint(expected.Veggies()) {
+		t.Fatalf("expectedVeggies %v, got %v", expected.Veggies(), svc.Veggies())
+	}
+
+	_, err = From[Service](&LayerA{}).Then(&LayerB{}).Then(&brokenLayer{}).Then(&LayerC{}).Result()
+	if err == nil || err.Error() != "then 1: layer '*cake.brokenLayer': field Service not found" {
+		t.Fatalf("expected the error of the broken layer, got %v", err)
+	}
+}